@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"vodeno/pkg/apikey"
+	"vodeno/pkg/auth"
+)
+
+// errUnknownCommand is returned for unknown apikey subcommands.
+var errUnknownCommand = errors.New("unknown command, expected one of: issue, rotate, revoke, list")
+
+// runAPIKeyCommand manages API keys from command line, e.g. to issue the first admin key.
+// Plaintext of issued keys is printed once and can't be retrieved later.
+//
+// Usage:
+//
+//	main apikey issue -name NAME -scopes SCOPE[,SCOPE...] [-tenant TENANT] [-ttl DURATION]
+//	main apikey rotate -id ID
+//	main apikey revoke -id ID
+//	main apikey list
+func runAPIKeyCommand(ctx context.Context, svc apikey.Service, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errUnknownCommand
+	}
+
+	fs := flag.NewFlagSet("apikey "+args[0], flag.ContinueOnError)
+	name := fs.String("name", "", "name of the key")
+	tenant := fs.String("tenant", "default", "tenant of the key")
+	scopes := fs.String("scopes", "", "comma separated scopes of the key, e.g. clients:read,clients:write")
+	ttl := fs.Duration("ttl", 0, "time after which the key expires, zero means never")
+	id := fs.Int("id", 0, "ID of the key")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "issue":
+		if *name == "" {
+			return errors.New("-name is required")
+		}
+		var expireTime *time.Time
+		if *ttl > 0 {
+			t := time.Now().Add(*ttl)
+			expireTime = &t
+		}
+		var keyScopes []auth.Scope
+		for _, s := range strings.Split(*scopes, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				keyScopes = append(keyScopes, auth.Scope(s))
+			}
+		}
+		plaintext, key, err := svc.Issue(ctx, *name, *tenant, keyScopes, expireTime)
+		if err != nil {
+			return err
+		}
+		return printKey(out, plaintext, key)
+	case "rotate":
+		plaintext, key, err := svc.Rotate(ctx, *id)
+		if err != nil {
+			return err
+		}
+		return printKey(out, plaintext, key)
+	case "revoke":
+		return svc.Revoke(ctx, *id)
+	case "list":
+		keys, err := svc.List(ctx)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(out)
+		for _, k := range keys {
+			if err := enc.Encode(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return errUnknownCommand
+	}
+}
+
+func printKey(out io.Writer, plaintext string, key *apikey.Key) error {
+	if _, err := fmt.Fprintf(out, "%s\n\nStore the key now, it won't be shown again.\n", plaintext); err != nil {
+		return err
+	}
+	return json.NewEncoder(out).Encode(key)
+}
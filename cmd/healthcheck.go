@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// healthCheckTimeout limits time of healthcheck command.
+const healthCheckTimeout = 5 * time.Second
+
+// runHealthCheck checks readiness of the service listening on port of this host.
+// It's used by docker healthcheck, as the image has neither shell nor curl.
+//
+// Usage:
+//
+//	main healthcheck
+func runHealthCheck(ctx context.Context, port int) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://localhost:%d/readyz", port), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("service is not ready: %s", resp.Status)
+	}
+	return nil
+}
@@ -3,15 +3,32 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	"vodeno/pkg/apikey"
+	"vodeno/pkg/audit"
+	"vodeno/pkg/bounce"
 	"vodeno/pkg/client"
 	"vodeno/pkg/config"
 	db2 "vodeno/pkg/db"
+	"vodeno/pkg/events"
+	"vodeno/pkg/grpcapi"
+	"vodeno/pkg/health"
+	"vodeno/pkg/mail"
+	"vodeno/pkg/metrics"
 	"vodeno/pkg/middleware"
+	"vodeno/pkg/oauth"
+	"vodeno/pkg/openapi"
+	"vodeno/pkg/problem"
+	"vodeno/pkg/ratelimit"
+	"vodeno/pkg/requestid"
+	"vodeno/pkg/suppression"
+	"vodeno/pkg/tracing"
+	"vodeno/pkg/webhook"
 
 	"github.com/go-chi/chi/v5"
 	_ "github.com/lib/pq"
@@ -21,35 +38,175 @@ import (
 func main() {
 	ctx := context.Background()
 	logger := logrus.New()
+	logger.AddHook(requestid.Hook{})
 
-	r := chi.NewRouter()
-	r.Use(
-		middleware.LoggerMiddleware(logger),
-		middleware.AuthenticationMiddleware,
-	)
 	cfg, err := config.Load()
 	if err != nil {
 		logger.Panic(err)
 	}
+	if err := configureLogger(logger, cfg.Log); err != nil {
+		logger.Panic(err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		if err := runHealthCheck(ctx, cfg.Port); err != nil {
+			logger.Fatal(err)
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		logger.Panic(err)
+	}
+
+	timeoutMiddleware, err := middleware.TimeoutMiddleware(cfg.HTTP)
+	if err != nil {
+		logger.Panic(err)
+	}
+
+	r := chi.NewRouter()
+	r.Use(tracing.Middleware)
+	r.Use(middleware.LoggerMiddleware(logger))
+	r.Use(metrics.Middleware)
+	r.Use(middleware.RecoverMiddleware(logger))
+	r.Use(middleware.CORSMiddleware(cfg.HTTP.CORS))
+	r.Use(timeoutMiddleware)
+	r.NotFound(problem.NotFound)
+	r.MethodNotAllowed(problem.MethodNotAllowed)
 
 	db, err := db2.OpenDB(cfg.DB)
 	if err != nil {
 		logger.Panic(err)
 	}
+	if err := metrics.RegisterDB(db.DB, cfg.DB.DbName); err != nil {
+		logger.Panic(err)
+	}
+
+	apiKeyService := apikey.NewTracedService(apikey.NewService(apikey.NewRepo(db), cfg.Auth.CacheTTL))
+	apiKeyHandler := apikey.NewHandler(logger, apiKeyService)
+
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		if err := runAPIKeyCommand(ctx, apiKeyService, os.Args[2:], os.Stdout); err != nil {
+			logger.Fatal(err)
+		}
+		return
+	}
+
+	dkimSigner, err := mail.NewDKIMSigner(cfg.Mail.DKIM)
+	if err != nil {
+		logger.Panic(err)
+	}
+	transport := mail.NewLimitedTransport(
+		mail.NewSMTPTransport(cfg.Mail.SMTP),
+		mail.NewPostgresLimiter(logger, db, cfg.Mail.RateLimit.LeaseTTL),
+		cfg.Mail.RateLimit,
+	)
+	mailer := mail.NewMailer(cfg.Mail.From, transport, dkimSigner)
+
+	suppressionService := suppression.NewTracedService(suppression.NewService(suppression.NewRepo(logger, db), cfg.Suppression.Unsubscribe))
+	suppressionHandler := suppression.NewHandler(logger, suppressionService)
+
+	webhookRepo := webhook.NewRepo(db)
+	webhookSender := webhook.NewSender(cfg.Webhook.Timeout)
+	webhookHandler := webhook.NewHandler(logger, webhook.NewTracedService(webhook.NewService(webhookRepo, webhookSender)))
+	webhookDispatcher := webhook.NewDispatcher(logger, webhookRepo, webhookSender, cfg.Webhook)
+	webhookDispatcher.Start(ctx)
+
+	// events are delivered to webhooks subscribed to them too.
+	publisher := webhook.NewPublisher(events.NewPublisher(db), webhookRepo)
+	listener, err := events.NewListener(logger, cfg.DB)
+	if err != nil {
+		logger.Panic(err)
+	}
+	broker := events.NewBroker(logger)
+	go broker.Run(ctx, listener.Notify)
+	eventsHandler := events.NewHandler(logger, broker, cfg.Events.KeepAlive)
 
-	repo := client.NewRepo(db)
-	service := client.NewService(repo)
+	repo := client.NewRepo(logger, db)
+	service := client.NewTracedService(
+		client.NewService(logger, repo, mailer, suppressionService, publisher, cfg.Suppression.RejectOnAdd),
+	)
 	handler := client.NewHandler(logger, service)
 
-	watcher := client.NewWatcher(logger, repo, cfg.Watcher.TickPeriod)
+	bounceHandler := bounce.NewHandler(logger,
+		bounce.NewTracedService(bounce.NewService(logger, service, suppressionService, publisher)))
+
+	watcher := client.NewWatcher(logger, repo, publisher, cfg.Watcher.TickPeriod)
 	watcher.Start(ctx)
 
-	handler.AddRoutes(r)
+	auditService := audit.NewTracedService(audit.NewService(audit.NewRepo(db)))
+	auditHandler := audit.NewHandler(logger, auditService)
+
+	// access tokens are accepted besides API keys when JWKS is set.
+	var tokenAuthenticator middleware.Authenticator
+	if cfg.Auth.JWT.JWKS != "" {
+		keys, err := oauth.NewKeySet(ctx, logger, cfg.Auth.JWT.JWKS, cfg.Auth.JWT.RefreshPeriod)
+		if err != nil {
+			logger.Panic(err)
+		}
+		verifier, err := oauth.NewVerifier(keys, cfg.Auth.JWT)
+		if err != nil {
+			logger.Panic(err)
+		}
+		tokenAuthenticator = verifier
+	}
+	authMiddleware := middleware.KeyOrBearerAuthenticationMiddleware(logger, apiKeyService, tokenAuthenticator)
+
+	rateLimitStore, err := ratelimit.NewStore(logger, db, cfg.RateLimit.Store)
+	if err != nil {
+		logger.Panic(err)
+	}
+	limiter := ratelimit.NewLimiter(logger, rateLimitStore, cfg.RateLimit)
+
+	healthHandler := health.NewHandler(logger, cfg.Health.CheckTimeout)
+	healthHandler.AddCheck("db", health.CheckerFunc(db.PingContext))
+	healthHandler.AddCheck("schema", health.CheckerFunc(func(ctx context.Context) error {
+		return db2.CheckSchemaVersion(ctx, db)
+	}))
+	healthHandler.AddCheck("watcher", watcher)
+	healthHandler.AddCheck("events", health.CheckerFunc(func(context.Context) error {
+		return listener.Ping()
+	}))
+
+	healthHandler.AddRoutes(r)
+	openapi.NewHandler(logger, cfg.OpenAPI.Docs).AddRoutes(r)
+	r.Group(func(r chi.Router) {
+		r.Use(limiter.Middleware("public"))
+		suppressionHandler.AddPublicRoutes(r)
+	})
+	suppressionHandler.AddOneClickRoutes(r)
+	r.Group(func(r chi.Router) {
+		// requests are limited by client IP before authentication too, invalid keys aren't cached
+		// and would query the database unthrottled otherwise.
+		r.Use(limiter.Middleware("auth"))
+		r.Use(authMiddleware)
+		// every group of routes has its own limit, rejected requests are not audited.
+		for _, g := range []struct {
+			name    string
+			handler interface{ AddRoutes(chi.Router) }
+		}{
+			{"clients", handler},
+			{"suppressions", suppressionHandler},
+			{"bounces", bounceHandler},
+			{"apikeys", apiKeyHandler},
+			{"audit", auditHandler},
+			{"events", eventsHandler},
+			{"webhooks", webhookHandler},
+		} {
+			g := g
+			r.Group(func(r chi.Router) {
+				r.Use(limiter.Middleware(g.name))
+				r.Use(audit.Middleware(logger, auditService))
+				g.handler.AddRoutes(r)
+			})
+		}
+	})
 
 	pid := os.Getpid()
 	srvAddr := fmt.Sprintf(":%d", cfg.Port)
 
-	srv := newServer(srvAddr, r)
+	srv := newServer(srvAddr, r, cfg.HTTP)
 	go func() {
 		logger.WithFields(logrus.Fields{
 			"PID":  pid,
@@ -60,26 +217,88 @@ func main() {
 		}
 	}()
 
+	adminAddr := fmt.Sprintf(":%d", cfg.AdminPort)
+	adminRouter := chi.NewRouter()
+	adminRouter.Handle("/metrics", metrics.Handler())
+
+	adminSrv := newServer(adminAddr, adminRouter, cfg.HTTP)
+	go func() {
+		logger.WithField("addr", adminAddr).Info("starting admin srv")
+		if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.WithError(err).Error("starting admin srv failed")
+		}
+	}()
+
+	grpcAddr := fmt.Sprintf(":%d", cfg.GRPCPort)
+	grpcSrv := grpcapi.NewGRPCServer(logger, apiKeyService, tokenAuthenticator, auditService, limiter, grpcapi.NewServer(logger, service))
+	go func() {
+		logger.WithField("addr", grpcAddr).Info("starting gRPC srv")
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			logger.WithError(err).Error("starting gRPC srv failed")
+			return
+		}
+		if err := grpcSrv.Serve(lis); err != nil {
+			logger.WithError(err).Error("starting gRPC srv failed")
+		}
+	}()
+
 	// Graceful shutdown.
 	term := make(chan os.Signal, 1)
 	signal.Notify(term, os.Interrupt, syscall.SIGTERM)
 
 	<-term
+	// new requests are sent to other instances while those in progress finish.
+	healthHandler.Shutdown()
+	time.Sleep(cfg.Health.ShutdownDelay)
+
 	watcher.Stop()
+	webhookDispatcher.Stop()
+	eventsHandler.Shutdown()
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.WithError(err).Error("shutting down API failed")
 	}
+	grpcSrv.GracefulStop()
+	if err := adminSrv.Shutdown(ctx); err != nil {
+		logger.WithError(err).Error("shutting down admin srv failed")
+	}
+	if err := listener.Close(); err != nil {
+		logger.WithError(err).Error("closing events listener failed")
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		logger.WithError(err).Error("flushing spans failed")
+	}
 	logrus.Info("exiting")
 }
 
+// configureLogger sets format and level of logger.
+func configureLogger(logger *logrus.Logger, cfg config.LogConfig) error {
+	switch cfg.Format {
+	case "json":
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	case "text":
+		logger.SetFormatter(&logrus.TextFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %q, expected json or text", cfg.Format)
+	}
+
+	level, err := logrus.ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	logger.SetLevel(level)
+	return nil
+}
+
 // newServer creates a new http.Server object.
-func newServer(serverAddr string, handler http.Handler) *http.Server {
+func newServer(serverAddr string, handler http.Handler, cfg config.HTTPConfig) *http.Server {
 	srv := &http.Server{
-		Addr:         serverAddr,
-		ReadTimeout:  600 * time.Second,
-		WriteTimeout: 900 * time.Second,
-		IdleTimeout:  600 * time.Second,
-		Handler:      handler,
+		Addr:              serverAddr,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		Handler:           handler,
 	}
 	return srv
 }
@@ -0,0 +1,11 @@
+CREATE TABLE attachment (
+    id SERIAL PRIMARY KEY,
+    mailing_id NUMERIC NOT NULL,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    content_id TEXT NOT NULL DEFAULT '',
+    data BYTEA NOT NULL,
+    insert_time timestamp with time zone NOT NULL
+);
+
+CREATE INDEX attachment_mailing_id ON attachment(mailing_id);
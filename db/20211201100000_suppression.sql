@@ -0,0 +1,5 @@
+CREATE TABLE suppression (
+    email TEXT PRIMARY KEY, -- lower-cased address.
+    reason TEXT NOT NULL,
+    insert_time timestamp with time zone NOT NULL
+);
@@ -0,0 +1,15 @@
+CREATE TABLE delivery (
+    id SERIAL PRIMARY KEY,
+    mailing_id NUMERIC NOT NULL,
+    entry_id INTEGER NOT NULL,
+    email TEXT NOT NULL, -- lower-cased address.
+    message_id TEXT NOT NULL,
+    status TEXT NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    insert_time timestamp with time zone NOT NULL,
+    update_time timestamp with time zone NOT NULL
+);
+
+-- bounces and complaints are matched by recipient and Message-ID.
+CREATE INDEX delivery_email ON delivery(email);
+CREATE INDEX delivery_message_id ON delivery(message_id);
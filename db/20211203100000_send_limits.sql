@@ -0,0 +1,16 @@
+-- per second message counters shared by all instances.
+CREATE TABLE send_rate (
+    key TEXT NOT NULL,
+    window_start timestamp with time zone NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (key, window_start)
+);
+
+-- concurrency slots shared by all instances.
+CREATE TABLE send_lease (
+    key TEXT NOT NULL,
+    slot INTEGER NOT NULL,
+    holder TEXT NOT NULL,
+    expire_time timestamp with time zone NOT NULL,
+    PRIMARY KEY (key, slot)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE api_key (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    hash TEXT NOT NULL, -- hex encoded SHA-256 of plaintext key.
+    create_time timestamp with time zone NOT NULL,
+    expire_time timestamp with time zone,
+    last_used_time timestamp with time zone,
+    revoke_time timestamp with time zone
+);
+
+CREATE UNIQUE INDEX api_key_hash ON api_key(hash);
@@ -0,0 +1,4 @@
+ALTER TABLE api_key ADD COLUMN scopes TEXT[] NOT NULL DEFAULT '{}';
+
+-- Keys issued before scopes were introduced had full access.
+UPDATE api_key SET scopes = '{admin}';
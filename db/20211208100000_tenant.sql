@@ -0,0 +1,49 @@
+-- Existing data and keys belong to the default tenant.
+ALTER TABLE api_key ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+
+ALTER TABLE entry ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE entry ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE attachment ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE attachment ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE delivery ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE delivery ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- every query is scoped by tenant, payload is unique within a tenant.
+DROP INDEX entry_id;
+DROP INDEX entry_payload;
+CREATE INDEX entry_tenant_id ON entry(tenant_id, id);
+CREATE INDEX entry_tenant_mailing_id ON entry(tenant_id, mailing_id);
+CREATE UNIQUE INDEX entry_payload ON entry(tenant_id, title, content);
+
+DROP INDEX attachment_mailing_id;
+CREATE INDEX attachment_tenant_mailing_id ON attachment(tenant_id, mailing_id);
+
+-- Row-level security is a backstop for queries missing tenant condition.
+-- Tenant scoped queries run as vodeno_tenant role with app.tenant_id setting,
+-- rows of other tenants are invisible to them. Table owner bypasses the policies.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'vodeno_tenant') THEN
+        CREATE ROLE vodeno_tenant NOLOGIN;
+    END IF;
+END
+$$;
+GRANT vodeno_tenant TO CURRENT_USER;
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON entry, attachment, delivery TO vodeno_tenant;
+GRANT USAGE ON SEQUENCE entry_id_seq, attachment_id_seq, delivery_id_seq TO vodeno_tenant;
+
+ALTER TABLE entry ENABLE ROW LEVEL SECURITY;
+CREATE POLICY entry_tenant ON entry
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE attachment ENABLE ROW LEVEL SECURITY;
+CREATE POLICY attachment_tenant ON attachment
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE delivery ENABLE ROW LEVEL SECURITY;
+CREATE POLICY delivery_tenant ON delivery
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,23 @@
+CREATE TABLE audit (
+    id SERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL,
+    action TEXT NOT NULL, -- method and route pattern, e.g. DELETE /clients/{id}.
+    targets TEXT[] NOT NULL DEFAULT '{}',
+    status INTEGER NOT NULL,
+    result TEXT NOT NULL,
+    insert_time timestamp with time zone NOT NULL
+);
+
+CREATE INDEX audit_tenant_id ON audit(tenant_id, id);
+
+-- audit trail is append-only, records can't be changed or removed.
+CREATE FUNCTION audit_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit is append-only';
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_append_only();
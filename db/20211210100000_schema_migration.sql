@@ -0,0 +1,18 @@
+-- versions of applied migrations, readiness probe checks that the latest one required by the service is applied.
+-- every next migration must insert its version.
+CREATE TABLE schema_migration (
+    version BIGINT PRIMARY KEY,
+    apply_time timestamp with time zone NOT NULL DEFAULT now()
+);
+
+INSERT INTO schema_migration (version) VALUES
+    (20211123163200),
+    (20211130120000),
+    (20211201100000),
+    (20211202100000),
+    (20211203100000),
+    (20211206100000),
+    (20211207100000),
+    (20211208100000),
+    (20211209100000),
+    (20211210100000);
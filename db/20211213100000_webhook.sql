@@ -0,0 +1,31 @@
+CREATE TABLE webhook_subscription (
+    id SERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL, -- HMAC-SHA256 key of payload signatures, it's needed in plaintext.
+    event_types TEXT[] NOT NULL,
+    create_time timestamp with time zone NOT NULL
+);
+
+CREATE INDEX webhook_subscription_tenant_id ON webhook_subscription(tenant_id, id);
+
+-- deliveries are both the queue of the dispatcher and the delivery log of subscriptions.
+CREATE TABLE webhook_delivery (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES webhook_subscription(id) ON DELETE CASCADE,
+    tenant_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    state TEXT NOT NULL, -- pending, delivered or failed.
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_status INTEGER NOT NULL DEFAULT 0, -- HTTP status of the last attempt, 0 if there was no response.
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_time timestamp with time zone NOT NULL,
+    insert_time timestamp with time zone NOT NULL,
+    update_time timestamp with time zone NOT NULL
+);
+
+CREATE INDEX webhook_delivery_subscription_id ON webhook_delivery(subscription_id, id);
+CREATE INDEX webhook_delivery_pending ON webhook_delivery(next_attempt_time) WHERE state = 'pending';
+
+INSERT INTO schema_migration (version) VALUES (20211213100000);
@@ -0,0 +1,10 @@
+-- token buckets of API request rate limits shared by all instances.
+CREATE TABLE rate_limit_bucket (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    update_time timestamp with time zone NOT NULL
+);
+
+CREATE INDEX rate_limit_bucket_update_time ON rate_limit_bucket(update_time);
+
+INSERT INTO schema_migration (version) VALUES (20211214100000);
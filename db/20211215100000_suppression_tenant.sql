@@ -0,0 +1,14 @@
+-- suppressions are kept per tenant, existing ones belong to the default tenant.
+ALTER TABLE suppression ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE suppression ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE suppression DROP CONSTRAINT suppression_pkey;
+ALTER TABLE suppression ADD PRIMARY KEY (tenant_id, email);
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON suppression TO vodeno_tenant;
+
+ALTER TABLE suppression ENABLE ROW LEVEL SECURITY;
+CREATE POLICY suppression_tenant ON suppression
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+INSERT INTO schema_migration (version) VALUES (20211215100000);
@@ -0,0 +1,174 @@
+package apikey
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"vodeno/pkg/audit"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/httpjson"
+	"vodeno/pkg/middleware"
+	"vodeno/pkg/problem"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator"
+	"github.com/sirupsen/logrus"
+)
+
+// Handler is a http handler for API keys administration.
+type Handler struct {
+	service   Service
+	validator *validator.Validate
+	log       *logrus.Logger
+}
+
+// NewHandler returns new instance of Handler.
+func NewHandler(log *logrus.Logger, svc Service) *Handler {
+	return &Handler{
+		service:   svc,
+		validator: problem.NewValidator(),
+		log:       log,
+	}
+}
+
+// AddRoutes adds API keys routes to router. They require admin scope.
+func (h *Handler) AddRoutes(router chi.Router) {
+	router.Route("/apikeys", func(r chi.Router) {
+		r.Use(middleware.RequireScope(auth.ScopeAdmin))
+		r.Post("/", h.issue)
+		r.Get("/", h.list)
+		r.Post("/{id}/rotate", h.rotate)
+		r.Delete("/{id}", h.revoke)
+	})
+}
+
+// IssueRequest is an issue handler request.
+type IssueRequest struct {
+	Name       string       `json:"name" validate:"required"`
+	Scopes     []auth.Scope `json:"scopes" validate:"required"`
+	ExpireTime *time.Time   `json:"expire_time"`
+}
+
+// issueResponse contains plaintext key, it's the only time the key is shown.
+type issueResponse struct {
+	Key    string `json:"key"`
+	APIKey *Key   `json:"api_key"`
+}
+
+// issue creates new API key.
+func (h *Handler) issue(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "issue")
+	var req IssueRequest
+
+	if err := httpjson.Decode(r, &req); err != nil {
+		logger.WithError(err).Error("failed to decode request")
+		problem.Write(w, r, httpjson.Problem(err))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logger.WithError(err).Error("request is not valid")
+		problem.Write(w, r, problem.Validation(err))
+		return
+	}
+
+	// Keys are issued for tenant of the caller.
+	tenantID, _ := auth.TenantFromContext(ctx)
+	plaintext, key, err := h.service.Issue(ctx, req.Name, tenantID, req.Scopes, req.ExpireTime)
+	if err != nil {
+		logger.WithError(err).Error("failed to issue API key")
+		if errors.Is(err, ErrInvalidScope) || errors.Is(err, ErrInvalidTenant) {
+			problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeValidationFailed, err.Error()))
+			return
+		}
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+	audit.AddTarget(ctx, "key_id", key.ID)
+	h.writeJSON(w, http.StatusCreated, issueResponse{Key: plaintext, APIKey: key})
+}
+
+// rotate replaces API key with a new one.
+func (h *Handler) rotate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "rotate")
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		logger.Error("id must be an integer")
+		problem.Write(w, r, problem.InvalidParameter("id", err))
+		return
+	}
+	logger = logger.WithField("key_id", id)
+
+	plaintext, key, err := h.service.Rotate(ctx, id)
+	if err != nil {
+		logger.WithError(err).Error("failed to rotate API key")
+		if errors.Is(err, ErrKeyNotFound) {
+			problem.Write(w, r, problem.New(http.StatusNotFound, problem.CodeNotFound, err.Error()))
+			return
+		}
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+	audit.AddTarget(ctx, "new_key_id", key.ID)
+	h.writeJSON(w, http.StatusCreated, issueResponse{Key: plaintext, APIKey: key})
+}
+
+// revoke revokes API key.
+func (h *Handler) revoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "revoke")
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		logger.Error("id must be an integer")
+		problem.Write(w, r, problem.InvalidParameter("id", err))
+		return
+	}
+	logger = logger.WithField("key_id", id)
+
+	if err := h.service.Revoke(ctx, id); err != nil {
+		logger.WithError(err).Error("failed to revoke API key")
+		if errors.Is(err, ErrKeyNotFound) {
+			problem.Write(w, r, problem.New(http.StatusNotFound, problem.CodeNotFound, err.Error()))
+			return
+		}
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type listResponse struct {
+	Keys []Key `json:"keys"`
+}
+
+// list lists API keys without their plaintext.
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "listKeys")
+
+	keys, err := h.service.List(ctx)
+	if err != nil {
+		logger.WithError(err).Error("failed to list API keys")
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, listResponse{Keys: keys})
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.log.WithError(err).Error("writing JSON to ResponseWriter failed")
+	}
+}
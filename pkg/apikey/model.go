@@ -0,0 +1,43 @@
+package apikey
+
+import (
+	"time"
+	"vodeno/pkg/auth"
+
+	"github.com/lib/pq"
+)
+
+// Key represents an API key. Only hash of the key is stored,
+// plaintext is shown once when the key is issued.
+type Key struct {
+	ID   int    `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	// TenantID is a tenant of requests authenticated with the key.
+	TenantID string `json:"tenant_id" db:"tenant_id"`
+	// Prefix is the beginning of plaintext key, it helps to identify keys.
+	Prefix string `json:"prefix" db:"prefix"`
+	Hash   string `json:"-" db:"hash"`
+	// Scopes are names of auth.Scope granted to the key.
+	Scopes       pq.StringArray `json:"scopes" db:"scopes"`
+	CreateTime   time.Time      `json:"create_time" db:"create_time"`
+	ExpireTime   *time.Time     `json:"expire_time,omitempty" db:"expire_time"`
+	LastUsedTime *time.Time     `json:"last_used_time,omitempty" db:"last_used_time"`
+	RevokeTime   *time.Time     `json:"revoke_time,omitempty" db:"revoke_time"`
+}
+
+// scopes returns scopes granted to the key.
+func (k Key) scopes() []auth.Scope {
+	scopes := make([]auth.Scope, 0, len(k.Scopes))
+	for _, s := range k.Scopes {
+		scopes = append(scopes, auth.Scope(s))
+	}
+	return scopes
+}
+
+// Active returns true if key is neither revoked nor expired at given time.
+func (k Key) Active(t time.Time) bool {
+	if k.RevokeTime != nil {
+		return false
+	}
+	return k.ExpireTime == nil || t.Before(*k.ExpireTime)
+}
@@ -0,0 +1,104 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+const tableName = "api_key" // api key table name.
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+// repo is postgresql implementation of Repository.
+type repo struct {
+	db *sqlx.DB
+}
+
+// NewRepo creates new instance of repo.
+func NewRepo(db *sqlx.DB) *repo {
+	return &repo{db: db}
+}
+
+func (r repo) Insert(ctx context.Context, k Key) (int, error) {
+	q := psql.Insert(tableName).
+		Columns("name", "tenant_id", "prefix", "hash", "scopes", "create_time", "expire_time").
+		Values(k.Name, k.TenantID, k.Prefix, k.Hash, k.Scopes, k.CreateTime, k.ExpireTime).
+		Suffix("RETURNING id")
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	if err := r.db.QueryRowxContext(ctx, query, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r repo) Get(ctx context.Context, id int) (*Key, error) {
+	return r.get(ctx, sq.Eq{"id": id})
+}
+
+func (r repo) GetByHash(ctx context.Context, hash string) (*Key, error) {
+	return r.get(ctx, sq.Eq{"hash": hash})
+}
+
+func (r repo) get(ctx context.Context, where sq.Eq) (*Key, error) {
+	q := psql.Select("*").From(tableName).Where(where)
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var k Key
+	if err := r.db.GetContext(ctx, &k, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r repo) List(ctx context.Context, tenantID *string) ([]Key, error) {
+	q := psql.Select("*").From(tableName).OrderBy("id")
+	if tenantID != nil {
+		q = q.Where(sq.Eq{"tenant_id": *tenantID})
+	}
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var keys []Key
+	if err := r.db.SelectContext(ctx, &keys, query, args...); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r repo) Revoke(ctx context.Context, id int, t time.Time) error {
+	q := psql.Update(tableName).
+		Set("revoke_time", t).
+		Where(sq.Eq{"id": id, "revoke_time": nil})
+	query, args, err := q.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r repo) Touch(ctx context.Context, id int, t time.Time) error {
+	q := psql.Update(tableName).Set("last_used_time", t).Where(sq.Eq{"id": id})
+	query, args, err := q.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,22 @@
+package apikey
+
+import (
+	"context"
+	"time"
+)
+
+// Repository is a repository interface.
+type Repository interface {
+	// Insert inserts Key to storage and returns its ID.
+	Insert(ctx context.Context, k Key) (int, error)
+	// Get queries single Key.
+	Get(ctx context.Context, id int) (*Key, error)
+	// GetByHash queries single Key by hash of its plaintext.
+	GetByHash(ctx context.Context, hash string) (*Key, error)
+	// List lists Keys of a tenant. If tenantID is nil, Keys of all tenants are listed.
+	List(ctx context.Context, tenantID *string) ([]Key, error)
+	// Revoke marks Key as revoked.
+	Revoke(ctx context.Context, id int, t time.Time) error
+	// Touch updates Key's last used time.
+	Touch(ctx context.Context, id int, t time.Time) error
+}
@@ -0,0 +1,233 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"vodeno/pkg/auth"
+)
+
+const (
+	keyPrefix    = "vdn_" // prefix of every plaintext key, it makes leaked keys easy to find.
+	prefixLength = 12     // length of plaintext prefix stored for identification.
+	secretBytes  = 32     // number of random bytes of a key.
+)
+
+var (
+	// ErrInvalidKey is returned when key doesn't exist, is revoked or expired.
+	ErrInvalidKey = errors.New("invalid API key")
+	// ErrKeyNotFound is returned when key with given ID doesn't exist.
+	ErrKeyNotFound = errors.New("API key not found")
+	// ErrInvalidTenant is returned when key is issued without tenant.
+	ErrInvalidTenant = errors.New("tenant is required")
+	// ErrInvalidScope is returned when key is issued without scopes or with unknown one.
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+// Service is a service interface.
+// Rotate, Revoke and List are limited to keys of tenant from context, if there is one.
+// Without tenant, e.g. when called from command line, keys of all tenants are managed.
+type Service interface {
+	// Issue creates new key of a tenant with given scopes.
+	// Returned plaintext key is not stored and can't be retrieved later.
+	Issue(ctx context.Context, name, tenantID string, scopes []auth.Scope, expireTime *time.Time) (string, *Key, error)
+	// Rotate issues new key with the same name, tenant, scopes and expiration and revokes the old one.
+	Rotate(ctx context.Context, id int) (string, *Key, error)
+	// Revoke revokes key.
+	Revoke(ctx context.Context, id int) error
+	// List lists keys.
+	List(ctx context.Context) ([]Key, error)
+
+	// Authenticate validates plaintext key and returns its Principal.
+	// Results are cached, so revocation may take up to cache TTL to take effect.
+	Authenticate(ctx context.Context, key string) (*auth.Principal, error)
+}
+
+// cacheEntry is a cached result of key validation.
+type cacheEntry struct {
+	key      *Key
+	loadTime time.Time
+}
+
+// service implements Service interface.
+type service struct {
+	repository Repository
+	cacheTTL   time.Duration
+
+	mu        sync.Mutex
+	cache     map[string]cacheEntry // keyed by hash, only existing keys are cached.
+	sweepTime time.Time             // last time stale entries were removed.
+}
+
+// NewService returns new Service.
+func NewService(repository Repository, cacheTTL time.Duration) Service {
+	return &service{
+		repository: repository,
+		cacheTTL:   cacheTTL,
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+func (s *service) Issue(
+	ctx context.Context, name, tenantID string, scopes []auth.Scope, expireTime *time.Time,
+) (string, *Key, error) {
+	if tenantID == "" {
+		return "", nil, ErrInvalidTenant
+	}
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	names := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if _, err := auth.ParseScope(string(scope)); err != nil {
+			return "", nil, fmt.Errorf("%w: %s", ErrInvalidScope, err)
+		}
+		names = append(names, string(scope))
+	}
+
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	plaintext := keyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	k := Key{
+		Name:       name,
+		TenantID:   tenantID,
+		Prefix:     plaintext[:prefixLength],
+		Hash:       hash(plaintext),
+		Scopes:     names,
+		CreateTime: time.Now(),
+		ExpireTime: expireTime,
+	}
+	id, err := s.repository.Insert(ctx, k)
+	if err != nil {
+		return "", nil, err
+	}
+	k.ID = id
+	return plaintext, &k, nil
+}
+
+func (s *service) Rotate(ctx context.Context, id int) (string, *Key, error) {
+	old, err := s.get(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	plaintext, k, err := s.Issue(ctx, old.Name, old.TenantID, old.scopes(), old.ExpireTime)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := s.Revoke(ctx, id); err != nil {
+		return "", nil, fmt.Errorf("key %d was issued but old key wasn't revoked: %w", k.ID, err)
+	}
+	return plaintext, k, nil
+}
+
+func (s *service) Revoke(ctx context.Context, id int) error {
+	k, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repository.Revoke(ctx, id, time.Now()); err != nil {
+		return err
+	}
+
+	// Revoked key stops working immediately at least on this instance.
+	s.mu.Lock()
+	delete(s.cache, k.Hash)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *service) List(ctx context.Context) ([]Key, error) {
+	if tenantID, ok := auth.TenantFromContext(ctx); ok {
+		return s.repository.List(ctx, &tenantID)
+	}
+	return s.repository.List(ctx, nil)
+}
+
+// get returns key with given ID. Keys of other tenants than the one in context are not found.
+func (s *service) get(ctx context.Context, id int) (*Key, error) {
+	k, err := s.repository.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		return nil, ErrKeyNotFound
+	}
+	if tenantID, ok := auth.TenantFromContext(ctx); ok && k.TenantID != tenantID {
+		return nil, ErrKeyNotFound
+	}
+	return k, nil
+}
+
+func (s *service) Authenticate(ctx context.Context, key string) (*auth.Principal, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, ErrInvalidKey
+	}
+	h := hash(key)
+	now := time.Now()
+
+	s.mu.Lock()
+	entry, ok := s.cache[h]
+	s.mu.Unlock()
+
+	if !ok || now.Sub(entry.loadTime) > s.cacheTTL {
+		k, err := s.repository.GetByHash(ctx, h)
+		if err != nil {
+			return nil, err
+		}
+		// Last used time is updated once per cache TTL to avoid write on every request.
+		if k != nil && k.Active(now) {
+			if err := s.repository.Touch(ctx, k.ID, now); err != nil {
+				return nil, err
+			}
+		}
+		entry = cacheEntry{key: k, loadTime: now}
+		if k != nil {
+			s.store(h, entry)
+		}
+	}
+
+	if entry.key == nil || !entry.key.Active(now) {
+		return nil, ErrInvalidKey
+	}
+	return &auth.Principal{
+		ID:       fmt.Sprintf("apikey:%d", entry.key.ID),
+		Name:     entry.key.Name,
+		TenantID: entry.key.TenantID,
+		Scopes:   entry.key.scopes(),
+	}, nil
+}
+
+// store caches entry and removes stale ones.
+func (s *service) store(h string, entry cacheEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cache[h] = entry
+	if entry.loadTime.Sub(s.sweepTime) < s.cacheTTL {
+		return
+	}
+	for k, e := range s.cache {
+		if entry.loadTime.Sub(e.loadTime) > s.cacheTTL {
+			delete(s.cache, k)
+		}
+	}
+	s.sweepTime = entry.loadTime
+}
+
+// hash returns hex encoded SHA-256 of plaintext key.
+// Keys are long random strings, so slow password hashing functions are not needed.
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,184 @@
+package apikey_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"vodeno/pkg/apikey"
+	"vodeno/pkg/auth"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRepo is in-memory implementation of apikey.Repository.
+type memoryRepo struct {
+	keys    []apikey.Key
+	touches int
+}
+
+func (m *memoryRepo) Insert(_ context.Context, k apikey.Key) (int, error) {
+	k.ID = len(m.keys) + 1
+	m.keys = append(m.keys, k)
+	return k.ID, nil
+}
+
+func (m *memoryRepo) Get(_ context.Context, id int) (*apikey.Key, error) {
+	for _, k := range m.keys {
+		if k.ID == id {
+			return &k, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryRepo) GetByHash(_ context.Context, hash string) (*apikey.Key, error) {
+	for _, k := range m.keys {
+		if k.Hash == hash {
+			return &k, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryRepo) List(_ context.Context, tenantID *string) ([]apikey.Key, error) {
+	var keys []apikey.Key
+	for _, k := range m.keys {
+		if tenantID == nil || k.TenantID == *tenantID {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (m *memoryRepo) Revoke(_ context.Context, id int, t time.Time) error {
+	m.keys[id-1].RevokeTime = &t
+	return nil
+}
+
+func (m *memoryRepo) Touch(_ context.Context, id int, t time.Time) error {
+	m.touches++
+	m.keys[id-1].LastUsedTime = &t
+	return nil
+}
+
+func TestService_Authenticate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("IssuedKeyIsValid", func(t *testing.T) {
+		repo := &memoryRepo{}
+		svc := apikey.NewService(repo, time.Minute)
+
+		plaintext, key, err := svc.Issue(ctx, "crm", "sales", []auth.Scope{auth.ScopeClientsRead}, nil)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(plaintext, key.Prefix))
+		require.NotContains(t, key.Hash, plaintext)
+
+		for i := 0; i < 3; i++ {
+			principal, err := svc.Authenticate(ctx, plaintext)
+			require.NoError(t, err)
+			require.Equal(t, "apikey:1", principal.ID)
+			require.Equal(t, "crm", principal.Name)
+			require.Equal(t, "sales", principal.TenantID)
+			require.Equal(t, []auth.Scope{auth.ScopeClientsRead}, principal.Scopes)
+		}
+		// Results are cached, so last used time is updated once.
+		require.Equal(t, 1, repo.touches)
+		require.NotNil(t, repo.keys[0].LastUsedTime)
+	})
+
+	t.Run("ReturnsErrorOnUnknownKey", func(t *testing.T) {
+		svc := apikey.NewService(&memoryRepo{}, time.Minute)
+
+		for _, key := range []string{"", "test", "vdn_unknown"} {
+			_, err := svc.Authenticate(ctx, key)
+			require.ErrorIs(t, err, apikey.ErrInvalidKey)
+		}
+	})
+
+	t.Run("ReturnsErrorOnExpiredKey", func(t *testing.T) {
+		svc := apikey.NewService(&memoryRepo{}, time.Minute)
+
+		expireTime := time.Now().Add(-time.Second)
+		plaintext, _, err := svc.Issue(ctx, "crm", "sales", []auth.Scope{auth.ScopeClientsRead}, &expireTime)
+		require.NoError(t, err)
+
+		_, err = svc.Authenticate(ctx, plaintext)
+		require.ErrorIs(t, err, apikey.ErrInvalidKey)
+	})
+
+	t.Run("ReturnsErrorOnRevokedKey", func(t *testing.T) {
+		svc := apikey.NewService(&memoryRepo{}, time.Minute)
+
+		plaintext, key, err := svc.Issue(ctx, "crm", "sales", []auth.Scope{auth.ScopeClientsRead}, nil)
+		require.NoError(t, err)
+		_, err = svc.Authenticate(ctx, plaintext)
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Revoke(ctx, key.ID))
+		_, err = svc.Authenticate(ctx, plaintext)
+		require.ErrorIs(t, err, apikey.ErrInvalidKey)
+
+		require.ErrorIs(t, svc.Revoke(ctx, 100), apikey.ErrKeyNotFound)
+	})
+
+	t.Run("RotatedKeyReplacesOldOne", func(t *testing.T) {
+		svc := apikey.NewService(&memoryRepo{}, time.Minute)
+
+		old, key, err := svc.Issue(ctx, "crm", "sales", []auth.Scope{auth.ScopeClientsRead}, nil)
+		require.NoError(t, err)
+
+		rotated, newKey, err := svc.Rotate(ctx, key.ID)
+		require.NoError(t, err)
+		require.NotEqual(t, old, rotated)
+		require.Equal(t, "crm", newKey.Name)
+		require.Equal(t, key.Scopes, newKey.Scopes)
+
+		_, err = svc.Authenticate(ctx, old)
+		require.ErrorIs(t, err, apikey.ErrInvalidKey)
+		_, err = svc.Authenticate(ctx, rotated)
+		require.NoError(t, err)
+	})
+
+	t.Run("ReturnsErrorOnInvalidScopes", func(t *testing.T) {
+		svc := apikey.NewService(&memoryRepo{}, time.Minute)
+
+		for _, scopes := range [][]auth.Scope{nil, {"clients:everything"}} {
+			_, _, err := svc.Issue(ctx, "crm", "sales", scopes, nil)
+			require.ErrorIs(t, err, apikey.ErrInvalidScope)
+		}
+	})
+
+	t.Run("ReturnsErrorOnMissingTenant", func(t *testing.T) {
+		svc := apikey.NewService(&memoryRepo{}, time.Minute)
+
+		_, _, err := svc.Issue(ctx, "crm", "", []auth.Scope{auth.ScopeClientsRead}, nil)
+		require.ErrorIs(t, err, apikey.ErrInvalidTenant)
+	})
+}
+
+func TestService_tenants(t *testing.T) {
+	svc := apikey.NewService(&memoryRepo{}, time.Minute)
+
+	_, sales, err := svc.Issue(context.Background(), "crm", "sales", []auth.Scope{auth.ScopeAdmin}, nil)
+	require.NoError(t, err)
+	_, support, err := svc.Issue(context.Background(), "helpdesk", "support", []auth.Scope{auth.ScopeAdmin}, nil)
+	require.NoError(t, err)
+
+	ctx := auth.NewContext(context.Background(), &auth.Principal{ID: "apikey:1", TenantID: "sales"})
+
+	keys, err := svc.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, sales.ID, keys[0].ID)
+
+	_, _, err = svc.Rotate(ctx, support.ID)
+	require.ErrorIs(t, err, apikey.ErrKeyNotFound)
+	require.ErrorIs(t, svc.Revoke(ctx, support.ID), apikey.ErrKeyNotFound)
+
+	// Without tenant in context keys of all tenants are managed.
+	keys, err = svc.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.NoError(t, svc.Revoke(context.Background(), support.ID))
+}
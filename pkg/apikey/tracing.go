@@ -0,0 +1,58 @@
+package apikey
+
+import (
+	"context"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedService is a Service which records span of every call.
+type tracedService struct {
+	next Service
+}
+
+// NewTracedService wraps svc to record span of every call.
+func NewTracedService(svc Service) Service {
+	return tracedService{next: svc}
+}
+
+func (s tracedService) Issue(
+	ctx context.Context, name, tenantID string, scopes []auth.Scope, expireTime *time.Time,
+) (string, *Key, error) {
+	ctx, span := tracing.Start(ctx, "apikey.Service.Issue", trace.WithAttributes(attribute.String("tenant_id", tenantID)))
+	secret, key, err := s.next.Issue(ctx, name, tenantID, scopes, expireTime)
+	tracing.End(span, err)
+	return secret, key, err
+}
+
+func (s tracedService) Rotate(ctx context.Context, id int) (string, *Key, error) {
+	ctx, span := tracing.Start(ctx, "apikey.Service.Rotate", trace.WithAttributes(attribute.Int("id", id)))
+	secret, key, err := s.next.Rotate(ctx, id)
+	tracing.End(span, err)
+	return secret, key, err
+}
+
+func (s tracedService) Revoke(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "apikey.Service.Revoke", trace.WithAttributes(attribute.Int("id", id)))
+	err := s.next.Revoke(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (s tracedService) List(ctx context.Context) ([]Key, error) {
+	ctx, span := tracing.Start(ctx, "apikey.Service.List")
+	keys, err := s.next.List(ctx)
+	tracing.End(span, err)
+	return keys, err
+}
+
+func (s tracedService) Authenticate(ctx context.Context, key string) (*auth.Principal, error) {
+	ctx, span := tracing.Start(ctx, "apikey.Service.Authenticate")
+	principal, err := s.next.Authenticate(ctx, key)
+	tracing.End(span, err)
+	return principal, err
+}
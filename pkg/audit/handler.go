@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/middleware"
+	"vodeno/pkg/problem"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultLimit = 20
+	// maxLimit caps limit parameter, so a single request can't read the whole audit trail.
+	maxLimit = 100
+)
+
+// Handler is a http handler for audit trail.
+type Handler struct {
+	service Service
+	log     *logrus.Logger
+}
+
+// NewHandler returns new instance of Handler.
+func NewHandler(log *logrus.Logger, svc Service) *Handler {
+	return &Handler{
+		service: svc,
+		log:     log,
+	}
+}
+
+// AddRoutes adds audit routes to router. They require admin scope.
+func (h *Handler) AddRoutes(router chi.Router) {
+	router.With(middleware.RequireScope(auth.ScopeAdmin)).Get("/audit", h.list)
+}
+
+type listResponse struct {
+	Records []Record `json:"records"`
+}
+
+// list lists audit Records. It accepts actor, action, target, from and to (RFC 3339) filters
+// and limit and after_id pagination.
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "listAudit")
+
+	filter, err := FilterFromRequest(r)
+	if err != nil {
+		logger.WithError(err).Error("failed to get filter")
+		problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidParameter, err.Error()))
+		return
+	}
+	records, err := h.service.List(ctx, *filter)
+	if err != nil {
+		logger.WithError(err).Error("failed to get audit records")
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+	if len(records) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	// write after_id header.
+	w.Header().Add("after_id", strconv.Itoa(records[len(records)-1].ID))
+
+	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(listResponse{records}); err != nil {
+		logger.WithError(err).Error("writing JSON to ResponseWriter failed")
+	}
+}
+
+// FilterFromRequest returns Filter from request's query parameters. Limit must be positive,
+// it's capped at maxLimit.
+func FilterFromRequest(r *http.Request) (*Filter, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	filter := Filter{
+		Actor:  r.Form.Get("actor"),
+		Action: r.Form.Get("action"),
+		Target: r.Form.Get("target"),
+		Limit:  defaultLimit,
+	}
+
+	if limit := r.Form.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit: %w", err)
+		}
+		if l < 1 {
+			return nil, errors.New("invalid limit: must be at least 1")
+		}
+		if l > maxLimit {
+			l = maxLimit
+		}
+		filter.Limit = l
+	}
+	if afterIDStr := r.Form.Get("after_id"); afterIDStr != "" {
+		afterID, err := strconv.Atoi(afterIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid after_id: %w", err)
+		}
+		filter.AfterID = &afterID
+	}
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := r.Form.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*dst = &t
+		}
+	}
+	return &filter, nil
+}
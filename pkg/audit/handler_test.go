@@ -0,0 +1,36 @@
+package audit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vodeno/pkg/audit"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterFromRequest_limit(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		query       string
+		wantedLimit int
+		wantedError bool
+	}{
+		{name: "UsesDefaultLimit", query: "", wantedLimit: 20},
+		{name: "UsesGivenLimit", query: "limit=50", wantedLimit: 50},
+		{name: "CapsLimit", query: "limit=100000", wantedLimit: 100},
+		{name: "RejectsZeroLimit", query: "limit=0", wantedError: true},
+		{name: "RejectsNegativeLimit", query: "limit=-1", wantedError: true},
+		{name: "RejectsInvalidLimit", query: "limit=all", wantedError: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := audit.FilterFromRequest(httptest.NewRequest(http.MethodGet, "/audit?"+tt.query, nil))
+			if tt.wantedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantedLimit, filter.Limit)
+		})
+	}
+}
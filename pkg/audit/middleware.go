@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/requestid"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// recordTimeout limits time of storing Record, it's done even if request context is canceled.
+	recordTimeout = 5 * time.Second
+)
+
+// Recorder stores audit Records.
+type Recorder interface {
+	// Record appends Record to audit trail.
+	Record(ctx context.Context, r Record) error
+}
+
+// targetsKey is a context key of audited request's targets.
+type targetsKey struct{}
+
+// targets are collected by handlers during a request.
+type targets struct {
+	mu     sync.Mutex
+	values []string
+}
+
+// AddTarget adds object affected by audited request, e.g. ID from request body.
+// URL parameters are added automatically. It does nothing if request is not audited.
+func AddTarget(ctx context.Context, name string, value interface{}) {
+	t, ok := ctx.Value(targetsKey{}).(*targets)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	t.values = append(t.values, fmt.Sprintf("%s:%v", name, value))
+	t.mu.Unlock()
+}
+
+// Middleware records every mutating request (POST, PUT, PATCH and DELETE) in audit trail.
+// It must be used after authentication, Principal from context is the actor.
+// Failure to record is logged and doesn't change the response.
+func Middleware(log *logrus.Logger, recorder Recorder) func(next http.Handler) http.Handler {
+	logger := log.WithField("place", "audit")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			t := &targets{}
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), targetsKey{}, t)))
+
+			record(r.Context(), logger, recorder, newHTTPRecord(r, ww.Status(), t))
+		})
+	}
+}
+
+// Call records a mutating call of API other than HTTP, e.g. gRPC method, in audit trail like Middleware.
+// It runs call with context collecting targets, call returns HTTP status equivalent to its result.
+// Principal from ctx is the actor. Failure to record is logged.
+func Call(ctx context.Context, log *logrus.Logger, recorder Recorder, action string, call func(ctx context.Context) int) {
+	t := &targets{}
+	status := call(context.WithValue(ctx, targetsKey{}, t))
+	record(ctx, log.WithField("place", "audit"), recorder, newRecord(ctx, action, status, t))
+}
+
+// record stores rec, it's done even if ctx is canceled.
+func record(ctx context.Context, logger *logrus.Entry, recorder Recorder, rec Record) {
+	recordCtx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+	if err := recorder.Record(recordCtx, rec); err != nil {
+		logger.WithContext(ctx).WithError(err).WithFields(logrus.Fields{
+			"action": rec.Action,
+			"actor":  rec.Actor,
+		}).Error("failed to record audit")
+	}
+}
+
+// newHTTPRecord creates Record of finished request, action is its route pattern and URL parameters are targets.
+func newHTTPRecord(r *http.Request, status int, t *targets) Record {
+	if status == 0 { // nothing was written, net/http responds with 200.
+		status = http.StatusOK
+	}
+	action := r.Method + " " + r.URL.Path
+	var params []string
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			action = r.Method + " " + pattern
+		}
+		params = make([]string, 0, len(rctx.URLParams.Keys))
+		for i, key := range rctx.URLParams.Keys {
+			if key == "*" {
+				continue
+			}
+			params = append(params, key+":"+rctx.URLParams.Values[i])
+		}
+		sort.Strings(params)
+	}
+
+	rec := newRecord(r.Context(), action, status, t)
+	rec.Targets = append(params, rec.Targets...)
+	if rec.Targets == nil {
+		rec.Targets = []string{}
+	}
+	return rec
+}
+
+// newRecord creates Record of finished action with targets collected in t.
+func newRecord(ctx context.Context, action string, status int, t *targets) Record {
+	requestID, _ := requestid.FromContext(ctx)
+	rec := Record{
+		RequestID: requestID,
+		Action:    action,
+		Targets:   []string{},
+		Status:    status,
+		Result:    ResultSuccess,
+	}
+	if status >= http.StatusBadRequest {
+		rec.Result = ResultFailure
+	}
+	if principal, ok := auth.FromContext(ctx); ok {
+		rec.Actor = principal.ID
+		rec.TenantID = principal.TenantID
+	}
+
+	t.mu.Lock()
+	rec.Targets = append(rec.Targets, t.values...)
+	t.mu.Unlock()
+	return rec
+}
@@ -0,0 +1,107 @@
+package audit_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vodeno/pkg/audit"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/requestid"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// recorderFunc is an adapter to use function as audit.Recorder.
+type recorderFunc func(ctx context.Context, r audit.Record) error
+
+func (f recorderFunc) Record(ctx context.Context, r audit.Record) error {
+	return f(ctx, r)
+}
+
+func TestMiddleware(t *testing.T) {
+	log := logrus.New()
+	log.Out = io.Discard
+
+	for _, tt := range []struct {
+		name         string
+		method       string
+		path         string
+		wantedRecord *audit.Record
+	}{
+		{
+			name:   "RecordsURLParameters",
+			method: http.MethodDelete,
+			path:   "/clients/5",
+			wantedRecord: &audit.Record{
+				TenantID:  "sales",
+				Actor:     "apikey:1",
+				RequestID: "request-1",
+				Action:    "DELETE /clients/{id}",
+				Targets:   []string{"id:5"},
+				Status:    http.StatusNoContent,
+				Result:    audit.ResultSuccess,
+			},
+		},
+		{
+			name:   "RecordsTargetsAddedByHandler",
+			method: http.MethodPost,
+			path:   "/clients/send",
+			wantedRecord: &audit.Record{
+				TenantID:  "sales",
+				Actor:     "apikey:1",
+				RequestID: "request-1",
+				Action:    "POST /clients/send",
+				Targets:   []string{"mailing_id:7"},
+				Status:    http.StatusInternalServerError,
+				Result:    audit.ResultFailure,
+			},
+		},
+		{
+			name:   "SkipsReadOnlyRequests",
+			method: http.MethodGet,
+			path:   "/clients/5",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var records []audit.Record
+			recorder := recorderFunc(func(_ context.Context, r audit.Record) error {
+				records = append(records, r)
+				return nil
+			})
+
+			router := chi.NewRouter()
+			router.Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					principal := &auth.Principal{ID: "apikey:1", TenantID: "sales"}
+					ctx := requestid.NewContext(auth.NewContext(r.Context(), principal), "request-1")
+					next.ServeHTTP(w, r.WithContext(ctx))
+				})
+			})
+			router.Use(audit.Middleware(log, recorder))
+			router.Route("/clients", func(r chi.Router) {
+				r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {})
+				r.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusNoContent)
+				})
+				r.Post("/send", func(w http.ResponseWriter, r *http.Request) {
+					audit.AddTarget(r.Context(), "mailing_id", 7)
+					w.WriteHeader(http.StatusInternalServerError)
+				})
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.wantedRecord == nil {
+				require.Empty(t, records)
+				return
+			}
+			require.Len(t, records, 1)
+			require.Equal(t, *tt.wantedRecord, records[0])
+		})
+	}
+}
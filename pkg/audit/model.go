@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Result is an outcome of audited request.
+type Result string
+
+const (
+	ResultSuccess Result = "success" // request succeeded, status below 400.
+	ResultFailure Result = "failure" // request failed.
+)
+
+// Record is an audit trail record of a mutating request.
+type Record struct {
+	ID       int    `json:"id" db:"id"`
+	TenantID string `json:"-" db:"tenant_id"`
+	// Actor is an ID of authenticated Principal, e.g. apikey:1.
+	Actor     string `json:"actor" db:"actor"`
+	RequestID string `json:"request_id" db:"request_id"`
+	// Action is a method and route pattern of the request, e.g. DELETE /clients/{id}.
+	Action string `json:"action" db:"action"`
+	// Targets identify affected objects, e.g. id:5 or mailing_id:1.
+	Targets    pq.StringArray `json:"targets" db:"targets"`
+	Status     int            `json:"status" db:"status"`
+	Result     Result         `json:"result" db:"result"`
+	InsertTime time.Time      `json:"insert_time" db:"insert_time"`
+}
+
+// Filter filters listed Records. Empty fields are not used.
+type Filter struct {
+	Actor  string
+	Action string
+	Target string
+	From   *time.Time
+	To     *time.Time
+	// Limit and AfterID paginate Records like client.Cursor.
+	Limit   int
+	AfterID *int
+}
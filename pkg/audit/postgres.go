@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+const tableName = "audit" // audit table name.
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+// repo is postgresql implementation of Repository.
+type repo struct {
+	db *sqlx.DB
+}
+
+// NewRepo creates new instance of repo.
+func NewRepo(db *sqlx.DB) *repo {
+	return &repo{db: db}
+}
+
+func (r repo) Insert(ctx context.Context, rec Record) error {
+	q := psql.Insert(tableName).
+		Columns("tenant_id", "actor", "request_id", "action", "targets", "status", "result", "insert_time").
+		Values(rec.TenantID, rec.Actor, rec.RequestID, rec.Action, rec.Targets, rec.Status, rec.Result, rec.InsertTime)
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r repo) GetFilter(ctx context.Context, tenantID string, f Filter) ([]Record, error) {
+	q := psql.Select("*").From(tableName).Where(sq.Eq{"tenant_id": tenantID}).OrderBy("id")
+	if f.Actor != "" {
+		q = q.Where(sq.Eq{"actor": f.Actor})
+	}
+	if f.Action != "" {
+		q = q.Where(sq.Eq{"action": f.Action})
+	}
+	if f.Target != "" {
+		q = q.Where("? = ANY(targets)", f.Target)
+	}
+	if f.From != nil {
+		q = q.Where(sq.GtOrEq{"insert_time": *f.From})
+	}
+	if f.To != nil {
+		q = q.Where(sq.Lt{"insert_time": *f.To})
+	}
+	if f.AfterID != nil {
+		q = q.Where(sq.Gt{"id": *f.AfterID})
+	}
+	if f.Limit > 0 {
+		q = q.Limit(uint64(f.Limit))
+	}
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	if err := r.db.SelectContext(ctx, &records, query, args...); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,11 @@
+package audit
+
+import "context"
+
+// Repository is a repository interface. Records can't be updated nor deleted.
+type Repository interface {
+	// Insert inserts Record to storage.
+	Insert(ctx context.Context, r Record) error
+	// GetFilter gets Records of a tenant ordered by ID.
+	GetFilter(ctx context.Context, tenantID string, f Filter) ([]Record, error)
+}
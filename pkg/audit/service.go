@@ -0,0 +1,40 @@
+package audit
+
+import (
+	"context"
+	"time"
+	"vodeno/pkg/auth"
+)
+
+// Service is a service interface.
+type Service interface {
+	// Record appends Record to audit trail.
+	Record(ctx context.Context, r Record) error
+	// List lists Records of tenant from context.
+	List(ctx context.Context, f Filter) ([]Record, error)
+}
+
+// service implements Service interface.
+type service struct {
+	repository Repository
+}
+
+// NewService returns new Service.
+func NewService(repository Repository) Service {
+	return service{repository: repository}
+}
+
+func (s service) Record(ctx context.Context, r Record) error {
+	if r.InsertTime.IsZero() {
+		r.InsertTime = time.Now()
+	}
+	return s.repository.Insert(ctx, r)
+}
+
+func (s service) List(ctx context.Context, f Filter) ([]Record, error) {
+	tenantID, ok := auth.TenantFromContext(ctx)
+	if !ok {
+		return nil, auth.ErrNoTenant
+	}
+	return s.repository.GetFilter(ctx, tenantID, f)
+}
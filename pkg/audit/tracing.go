@@ -0,0 +1,30 @@
+package audit
+
+import (
+	"context"
+	"vodeno/pkg/tracing"
+)
+
+// tracedService is a Service which records span of every call.
+type tracedService struct {
+	next Service
+}
+
+// NewTracedService wraps svc to record span of every call.
+func NewTracedService(svc Service) Service {
+	return tracedService{next: svc}
+}
+
+func (s tracedService) Record(ctx context.Context, r Record) error {
+	ctx, span := tracing.Start(ctx, "audit.Service.Record")
+	err := s.next.Record(ctx, r)
+	tracing.End(span, err)
+	return err
+}
+
+func (s tracedService) List(ctx context.Context, f Filter) ([]Record, error) {
+	ctx, span := tracing.Start(ctx, "audit.Service.List")
+	records, err := s.next.List(ctx, f)
+	tracing.End(span, err)
+	return records, err
+}
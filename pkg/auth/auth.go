@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoTenant is returned when tenant scoped operation is called without a tenant in context.
+var ErrNoTenant = errors.New("tenant is missing in context")
+
+// principalKey is a context key of authenticated Principal.
+type principalKey struct{}
+
+// Scope is a permission granted to a caller.
+type Scope string
+
+const (
+	ScopeClientsRead  Scope = "clients:read"  // list and get entries.
+	ScopeClientsWrite Scope = "clients:write" // add and delete entries, attachments and suppressions.
+	ScopeMailingsSend Scope = "mailings:send" // send mailings.
+	ScopeBouncesWrite Scope = "bounces:write" // report bounces and complaints.
+	ScopeAdmin        Scope = "admin"         // manage API keys, implies all other scopes.
+)
+
+// Scopes lists all known scopes.
+var Scopes = []Scope{ScopeClientsRead, ScopeClientsWrite, ScopeMailingsSend, ScopeBouncesWrite, ScopeAdmin}
+
+// ParseScope returns Scope of given name or error if it's unknown.
+func ParseScope(name string) (Scope, error) {
+	for _, s := range Scopes {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown scope %q", name)
+}
+
+// Principal is an authenticated caller.
+type Principal struct {
+	// ID identifies caller, e.g. apikey:1.
+	ID string `json:"id"`
+	// Name is a human readable name of the caller.
+	Name string `json:"name"`
+	// TenantID identifies business unit of the caller, its data is isolated from other tenants.
+	TenantID string `json:"tenant_id"`
+	// Scopes are permissions granted to the caller.
+	Scopes []Scope `json:"scopes"`
+}
+
+// HasScope returns true if Principal was granted given scope or is an admin.
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// NewContext returns context with given Principal.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns Principal stored in context.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// TenantFromContext returns tenant of Principal stored in context.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	p, ok := FromContext(ctx)
+	if !ok || p.TenantID == "" {
+		return "", false
+	}
+	return p.TenantID, true
+}
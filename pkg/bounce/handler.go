@@ -0,0 +1,88 @@
+package bounce
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/httpjson"
+	"vodeno/pkg/middleware"
+	"vodeno/pkg/problem"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator"
+	"github.com/sirupsen/logrus"
+)
+
+// Handler is a http handler for bounces and complaints.
+type Handler struct {
+	service   Service
+	validator *validator.Validate
+	log       *logrus.Logger
+}
+
+// NewHandler returns new instance of Handler.
+func NewHandler(log *logrus.Logger, svc Service) *Handler {
+	return &Handler{
+		service:   svc,
+		validator: problem.NewValidator(),
+		log:       log,
+	}
+}
+
+// AddRoutes adds bounce routes to router. They require bounces:write scope.
+func (h *Handler) AddRoutes(router chi.Router) {
+	router.With(middleware.RequireScope(auth.ScopeBouncesWrite)).Post("/bounces", h.ingest)
+}
+
+// ingest accepts raw DSN or ARF message, or JSON array of Events when
+// Content-Type is application/json. Events are applied to the tenant which sent
+// the reported message, see Service.Process.
+func (h *Handler) ingest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "ingest")
+
+	var (
+		events []Event
+		err    error
+	)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		err = httpjson.Decode(r, &events)
+	} else {
+		body := httpjson.LimitBody(r, httpjson.MaxBodySize)
+		events, err = Parse(body)
+		if err != nil && body.Err() != nil { // parser may not wrap error of the body.
+			err = body.Err()
+		}
+	}
+	if err != nil {
+		logger.WithError(err).Error("failed to decode request")
+		var tooLarge *httpjson.TooLargeError
+		switch {
+		case errors.Is(err, ErrUnsupportedMessage):
+			problem.Write(w, r, problem.New(http.StatusUnprocessableEntity, problem.CodeUnsupported, err.Error()))
+		case mediaType == "application/json", errors.As(err, &tooLarge):
+			problem.Write(w, r, httpjson.Problem(err))
+		default:
+			problem.Write(w, r, problem.InvalidBody(err))
+		}
+		return
+	}
+
+	for _, e := range events {
+		if err := h.validator.Struct(e); err != nil {
+			logger.WithError(err).Error("request is not valid")
+			problem.Write(w, r, problem.Validation(err))
+			return
+		}
+	}
+
+	if err := h.service.Process(ctx, events); err != nil {
+		logger.WithError(err).Error("failed to process events")
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,34 @@
+package bounce
+
+// EventType is a type of delivery feedback.
+type EventType string
+
+const (
+	EventBounce    EventType = "bounce"    // message was not delivered.
+	EventComplaint EventType = "complaint" // recipient marked message as spam.
+)
+
+const (
+	BounceHard = "hard" // permanent failure, address must not be mailed again.
+	BounceSoft = "soft" // temporary failure.
+)
+
+// Event is a bounce or complaint about a sent message.
+// It's parsed from DSN and ARF reports or received as JSON from webhooks.
+type Event struct {
+	Type  EventType `json:"type" validate:"required,oneof=bounce complaint"`
+	Email string    `json:"email" validate:"required,email"`
+	// MessageID is a Message-ID of the original message, it's optional.
+	MessageID string `json:"message_id"`
+	// BounceType is set for bounces only.
+	BounceType string `json:"bounce_type" validate:"omitempty,oneof=hard soft"`
+	// Status is enhanced status code, e.g. 5.1.1.
+	Status string `json:"status"`
+	// Diagnostic is a human readable reason, e.g. SMTP response or feedback type.
+	Diagnostic string `json:"diagnostic"`
+}
+
+// Hard returns true for hard bounces.
+func (e Event) Hard() bool {
+	return e.Type == EventBounce && e.BounceType == BounceHard
+}
@@ -0,0 +1,210 @@
+package bounce
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+const (
+	reportDeliveryStatus = "delivery-status" // RFC 3464 DSN.
+	reportFeedback       = "feedback-report" // RFC 5965 ARF.
+)
+
+// ErrUnsupportedMessage is returned when message is neither DSN nor ARF report.
+var ErrUnsupportedMessage = errors.New("message is not a delivery status or feedback report")
+
+// report is a decoded multipart/report message.
+type report struct {
+	// fields are header blocks of machine-readable part (message/delivery-status or message/feedback-report).
+	fields []textproto.MIMEHeader
+	// original is a header of returned message.
+	original textproto.MIMEHeader
+}
+
+// Parse parses raw RFC 3464 DSN or RFC 5965 ARF message and returns its Events.
+// DSNs produce bounce Event for every failed recipient, ARF produces single complaint.
+func Parse(r io.Reader) ([]Event, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMessage, err)
+	}
+	if mediaType != "multipart/report" {
+		return nil, ErrUnsupportedMessage
+	}
+
+	reportType := strings.ToLower(params["report-type"])
+	rep, err := readReport(multipart.NewReader(msg.Body, params["boundary"]), "message/"+reportType)
+	if err != nil {
+		return nil, err
+	}
+
+	switch reportType {
+	case reportDeliveryStatus:
+		return rep.bounces(), nil
+	case reportFeedback:
+		return rep.complaints(), nil
+	default:
+		return nil, ErrUnsupportedMessage
+	}
+}
+
+// readReport reads parts of multipart/report message.
+func readReport(mr *multipart.Reader, fieldsType string) (*report, error) {
+	var rep report
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		body := decode(p)
+
+		switch mediaType {
+		case fieldsType:
+			if rep.fields, err = readFields(body); err != nil {
+				return nil, err
+			}
+		case "message/rfc822", "text/rfc822-headers":
+			// only headers are needed, body is ignored.
+			if rep.original, err = readHeader(bufio.NewReader(body)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(rep.fields) == 0 {
+		return nil, ErrUnsupportedMessage
+	}
+	return &rep, nil
+}
+
+// bounces returns bounce Events of DSN's failed recipients.
+func (r *report) bounces() []Event {
+	var events []Event
+	// first block contains per-message fields, the rest are per-recipient.
+	for _, f := range r.fields[1:] {
+		if !strings.EqualFold(f.Get("Action"), "failed") {
+			continue // delayed, delivered, relayed or expanded.
+		}
+		email := address(f.Get("Final-Recipient"))
+		if email == "" {
+			email = address(f.Get("Original-Recipient"))
+		}
+		if email == "" {
+			continue
+		}
+
+		status := f.Get("Status")
+		bounceType := BounceSoft
+		if strings.HasPrefix(status, "5") {
+			bounceType = BounceHard
+		}
+		events = append(events, Event{
+			Type:       EventBounce,
+			Email:      email,
+			MessageID:  r.messageID(),
+			BounceType: bounceType,
+			Status:     status,
+			Diagnostic: f.Get("Diagnostic-Code"),
+		})
+	}
+	return events
+}
+
+// complaints returns complaint Event of ARF report.
+func (r *report) complaints() []Event {
+	f := r.fields[0]
+	email := address(f.Get("Original-Rcpt-To"))
+	if email == "" && r.original != nil {
+		if to, err := mail.ParseAddress(r.original.Get("To")); err == nil {
+			email = to.Address
+		}
+	}
+	if email == "" {
+		return nil
+	}
+	return []Event{{
+		Type:       EventComplaint,
+		Email:      email,
+		MessageID:  r.messageID(),
+		Diagnostic: f.Get("Feedback-Type"),
+	}}
+}
+
+// messageID returns Message-ID of returned message.
+func (r *report) messageID() string {
+	if r.original == nil {
+		return ""
+	}
+	return strings.TrimSpace(r.original.Get("Message-Id"))
+}
+
+// readFields reads blocks of header fields separated by empty lines.
+func readFields(r io.Reader) ([]textproto.MIMEHeader, error) {
+	br := bufio.NewReader(r)
+	var blocks []textproto.MIMEHeader
+	for {
+		// skip empty lines between blocks.
+		for {
+			b, err := br.Peek(1)
+			if err == io.EOF {
+				return blocks, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if b[0] != '\r' && b[0] != '\n' {
+				break
+			}
+			if _, err := br.ReadByte(); err != nil {
+				return nil, err
+			}
+		}
+
+		h, err := readHeader(br)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, h)
+	}
+}
+
+// readHeader reads header block, it tolerates missing empty line at the end.
+func readHeader(br *bufio.Reader) (textproto.MIMEHeader, error) {
+	h, err := textproto.NewReader(br).ReadMIMEHeader()
+	if err != nil && (err != io.EOF || len(h) == 0) {
+		return nil, err
+	}
+	return h, nil
+}
+
+// decode decodes base64 encoded parts. Quoted-printable is decoded by multipart.Reader.
+func decode(p *multipart.Part) io.Reader {
+	if strings.EqualFold(p.Header.Get("Content-Transfer-Encoding"), "base64") {
+		return base64.NewDecoder(base64.StdEncoding, p)
+	}
+	return p
+}
+
+// address parses address type field, e.g. "rfc822; user@example.com".
+func address(field string) string {
+	if i := strings.Index(field, ";"); i >= 0 {
+		field = field[i+1:]
+	}
+	return strings.Trim(strings.TrimSpace(field), "<>")
+}
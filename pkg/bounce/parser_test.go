@@ -0,0 +1,149 @@
+package bounce_test
+
+import (
+	"strings"
+	"testing"
+	"vodeno/pkg/bounce"
+
+	"github.com/stretchr/testify/require"
+)
+
+const dsn = "From: MAILER-DAEMON@mx.example.com\r\n" +
+	"To: no-reply@vodeno.com\r\n" +
+	"Subject: Undelivered Mail Returned to Sender\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/report; report-type=delivery-status;\r\n" +
+	"\tboundary=\"BOUNDARY\"\r\n" +
+	"\r\n" +
+	"--BOUNDARY\r\n" +
+	"Content-Type: text/plain; charset=us-ascii\r\n" +
+	"\r\n" +
+	"Your message could not be delivered.\r\n" +
+	"--BOUNDARY\r\n" +
+	"Content-Type: message/delivery-status\r\n" +
+	"\r\n" +
+	"Reporting-MTA: dns; mx.example.com\r\n" +
+	"Arrival-Date: Mon, 29 Nov 2021 10:00:00 +0000\r\n" +
+	"\r\n" +
+	"Final-Recipient: rfc822; unknown@example.com\r\n" +
+	"Original-Recipient: rfc822;Unknown@example.com\r\n" +
+	"Action: failed\r\n" +
+	"Status: 5.1.1\r\n" +
+	"Diagnostic-Code: smtp; 550 5.1.1 <unknown@example.com>:\r\n" +
+	"    Recipient address rejected: User unknown\r\n" +
+	"\r\n" +
+	"Final-Recipient: rfc822; full@example.com\r\n" +
+	"Action: failed\r\n" +
+	"Status: 4.2.2\r\n" +
+	"Diagnostic-Code: smtp; 452 4.2.2 Mailbox full\r\n" +
+	"\r\n" +
+	"Final-Recipient: rfc822; slow@example.com\r\n" +
+	"Action: delayed\r\n" +
+	"Status: 4.4.1\r\n" +
+	"\r\n" +
+	"--BOUNDARY\r\n" +
+	"Content-Type: text/rfc822-headers\r\n" +
+	"\r\n" +
+	"From: no-reply@vodeno.com\r\n" +
+	"To: unknown@example.com\r\n" +
+	"Message-ID: <123.abc@vodeno.com>\r\n" +
+	"Subject: title\r\n" +
+	"--BOUNDARY--\r\n"
+
+const arf = "From: <abusedesk@example.com>\r\n" +
+	"To: <abuse@vodeno.com>\r\n" +
+	"Subject: FW: Earn money\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/report; report-type=feedback-report; boundary=\"part1\"\r\n" +
+	"\r\n" +
+	"--part1\r\n" +
+	"Content-Type: text/plain; charset=\"US-ASCII\"\r\n" +
+	"Content-Transfer-Encoding: 7bit\r\n" +
+	"\r\n" +
+	"This is an email abuse report.\r\n" +
+	"\r\n" +
+	"--part1\r\n" +
+	"Content-Type: message/feedback-report\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	// Feedback-Type: abuse\r\nUser-Agent: SomeGenerator/1.0\r\nVersion: 1\r\n
+	"RmVlZGJhY2stVHlwZTogYWJ1c2UNClVzZXItQWdlbnQ6IFNvbWVHZW5lcmF0b3IvMS4wDQpWZXJz\r\n" +
+	"aW9uOiAxDQo=\r\n" +
+	"\r\n" +
+	"--part1\r\n" +
+	"Content-Type: message/rfc822\r\n" +
+	"Content-Disposition: inline\r\n" +
+	"\r\n" +
+	"From: <no-reply@vodeno.com>\r\n" +
+	"To: Client <client@example.com>\r\n" +
+	"Message-ID: <456.def@vodeno.com>\r\n" +
+	"Subject: Earn money\r\n" +
+	"\r\n" +
+	"Spam spam spam\r\n" +
+	"--part1--\r\n"
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		message      string
+		wantedEvents []bounce.Event
+		wantedErr    error
+	}{
+		{
+			name:    "DSN",
+			message: dsn,
+			wantedEvents: []bounce.Event{
+				{
+					Type:       bounce.EventBounce,
+					Email:      "unknown@example.com",
+					MessageID:  "<123.abc@vodeno.com>",
+					BounceType: bounce.BounceHard,
+					Status:     "5.1.1",
+					Diagnostic: "smtp; 550 5.1.1 <unknown@example.com>: Recipient address rejected: User unknown",
+				},
+				{
+					Type:       bounce.EventBounce,
+					Email:      "full@example.com",
+					MessageID:  "<123.abc@vodeno.com>",
+					BounceType: bounce.BounceSoft,
+					Status:     "4.2.2",
+					Diagnostic: "smtp; 452 4.2.2 Mailbox full",
+				},
+			},
+		},
+		{
+			name:    "ARF",
+			message: arf,
+			wantedEvents: []bounce.Event{
+				{
+					Type:       bounce.EventComplaint,
+					Email:      "client@example.com",
+					MessageID:  "<456.def@vodeno.com>",
+					Diagnostic: "abuse",
+				},
+			},
+		},
+		{
+			name:      "ReturnsErrorOnRegularMessage",
+			message:   "From: a@example.com\r\nContent-Type: text/plain\r\n\r\nhello",
+			wantedErr: bounce.ErrUnsupportedMessage,
+		},
+		{
+			name: "ReturnsErrorOnUnknownReport",
+			message: "From: a@example.com\r\n" +
+				"Content-Type: multipart/report; report-type=disposition-notification; boundary=b\r\n\r\n" +
+				"--b\r\nContent-Type: message/disposition-notification\r\n\r\nDisposition: displayed\r\n--b--\r\n",
+			wantedErr: bounce.ErrUnsupportedMessage,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := bounce.Parse(strings.NewReader(tt.message))
+			if tt.wantedErr != nil {
+				require.ErrorIs(t, err, tt.wantedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantedEvents, events)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package bounce
+
+import (
+	"context"
+	"strings"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/client"
+	"vodeno/pkg/events"
+	"vodeno/pkg/suppression"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Deliveries updates statuses of sent messages.
+type Deliveries interface {
+	// UpdateDelivery updates status of a message sent to email.
+	// It returns false if there is no such message.
+	UpdateDelivery(ctx context.Context, email, messageID string, status client.DeliveryStatus, detail string) (bool, error)
+	// DeliveryTenant returns tenant which sent message to email, in any tenant.
+	// It returns false if there is no such message.
+	DeliveryTenant(ctx context.Context, email, messageID string) (string, bool, error)
+}
+
+// Suppressions is a list of addresses which must not be mailed.
+type Suppressions interface {
+	// Add adds address to suppression list.
+	Add(ctx context.Context, s suppression.Suppression) error
+}
+
+// Service is a service interface.
+type Service interface {
+	// Process marks deliveries matching Events as bounced or complained.
+	// Hard bounced and complaining addresses are suppressed.
+	// Events with message ID are applied to the tenant which sent the message, others
+	// to the tenant from context, see tenantContext.
+	Process(ctx context.Context, events []Event) error
+}
+
+// service implements Service interface.
+type service struct {
+	deliveries   Deliveries
+	suppressions Suppressions
+	events       events.Publisher
+	log          *logrus.Entry
+}
+
+// NewService returns new Service.
+func NewService(
+	logger *logrus.Logger, deliveries Deliveries, suppressions Suppressions, publisher events.Publisher,
+) Service {
+	return service{
+		deliveries:   deliveries,
+		suppressions: suppressions,
+		events:       publisher,
+		log:          logger.WithField("place", "bounce"),
+	}
+}
+
+func (s service) Process(ctx context.Context, batch []Event) error {
+	for _, e := range batch {
+		ctx, err := s.tenantContext(ctx, e)
+		if err != nil {
+			return err
+		}
+		tenantID, _ := auth.TenantFromContext(ctx)
+
+		status, eventType := client.DeliveryBounced, events.MessageBounced
+		if e.Type == EventComplaint {
+			status, eventType = client.DeliveryComplained, events.MessageComplained
+		}
+		detail := strings.TrimSpace(strings.Join([]string{e.BounceType, e.Status, e.Diagnostic}, " "))
+
+		found, err := s.deliveries.UpdateDelivery(ctx, e.Email, e.MessageID, status, detail)
+		if err != nil {
+			return err
+		}
+		if !found {
+			// Address is suppressed anyway, message could be sent before deliveries were recorded.
+			s.log.WithContext(ctx).WithFields(logrus.Fields{
+				"email":      e.Email,
+				"message_id": e.MessageID,
+			}).Warn("no delivery matching event")
+		}
+		// event is informational, failure doesn't stop processing.
+		if err := s.events.Publish(ctx, events.Event{
+			Type: eventType, TenantID: tenantID, Email: e.Email, Detail: detail, Time: time.Now(),
+		}); err != nil {
+			s.log.WithContext(ctx).WithError(err).Warn("failed to publish event")
+		}
+
+		var reason suppression.Reason
+		switch {
+		case e.Hard():
+			reason = suppression.ReasonHardBounce
+		case e.Type == EventComplaint:
+			reason = suppression.ReasonComplaint
+		default:
+			continue
+		}
+		if err := s.suppressions.Add(ctx, suppression.Suppression{Email: e.Email, Reason: reason}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tenantContext returns ctx scoped to tenant which sent the message of Event.
+//
+// A single bounce mailbox or provider webhook receives reports of all tenants, so the
+// tenant is looked up by message ID. Events without message ID, or of unknown messages,
+// stay in tenant of the caller: matching by email alone would let a tenant suppress
+// recipients of other tenants.
+func (s service) tenantContext(ctx context.Context, e Event) (context.Context, error) {
+	if e.MessageID == "" {
+		return ctx, nil
+	}
+	tenantID, found, err := s.deliveries.DeliveryTenant(ctx, e.Email, e.MessageID)
+	if err != nil || !found {
+		return ctx, err
+	}
+	p, ok := auth.FromContext(ctx)
+	if !ok || p.TenantID == tenantID {
+		return ctx, nil
+	}
+	scoped := *p
+	scoped.TenantID = tenantID
+	return auth.NewContext(ctx, &scoped), nil
+}
@@ -0,0 +1,104 @@
+package bounce_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/bounce"
+	"vodeno/pkg/client"
+	"vodeno/pkg/events"
+	"vodeno/pkg/suppression"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryDeliveries is in-memory implementation of bounce.Deliveries, keyed by message ID.
+type memoryDeliveries struct {
+	tenants  map[string]string // tenant of message.
+	statuses map[string]client.DeliveryStatus
+}
+
+func (m memoryDeliveries) UpdateDelivery(
+	ctx context.Context, _, messageID string, status client.DeliveryStatus, _ string,
+) (bool, error) {
+	tenantID, _ := auth.TenantFromContext(ctx)
+	if m.tenants[messageID] != tenantID {
+		return false, nil
+	}
+	m.statuses[messageID] = status
+	return true, nil
+}
+
+func (m memoryDeliveries) DeliveryTenant(_ context.Context, _, messageID string) (string, bool, error) {
+	tenantID, ok := m.tenants[messageID]
+	return tenantID, ok, nil
+}
+
+// memorySuppressions records suppressed addresses by tenant.
+type memorySuppressions map[string][]string
+
+func (m memorySuppressions) Add(ctx context.Context, s suppression.Suppression) error {
+	tenantID, _ := auth.TenantFromContext(ctx)
+	m[tenantID] = append(m[tenantID], s.Email)
+	return nil
+}
+
+// memoryPublisher records published events.
+type memoryPublisher struct {
+	events []events.Event
+}
+
+func (m *memoryPublisher) Publish(_ context.Context, events ...events.Event) error {
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func TestService_Process(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		messageID  string
+		wantTenant string
+		wantStatus client.DeliveryStatus
+	}{
+		{
+			name:       "AppliesToTenantOfMessage",
+			messageID:  "<2@vodeno.com>",
+			wantTenant: "t2",
+			wantStatus: client.DeliveryBounced,
+		},
+		{
+			name:       "AppliesToCallerTenantWithoutMessageID",
+			wantTenant: "t1",
+		},
+		{
+			name:       "AppliesToCallerTenantForUnknownMessage",
+			messageID:  "<unknown@vodeno.com>",
+			wantTenant: "t1",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveries := memoryDeliveries{
+				tenants:  map[string]string{"<2@vodeno.com>": "t2"},
+				statuses: map[string]client.DeliveryStatus{},
+			}
+			suppressions := memorySuppressions{}
+			publisher := &memoryPublisher{}
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			svc := bounce.NewService(logger, deliveries, suppressions, publisher)
+
+			ctx := auth.NewContext(context.Background(), &auth.Principal{ID: "apikey:1", TenantID: "t1"})
+			err := svc.Process(ctx, []bounce.Event{{
+				Type: bounce.EventBounce, Email: "client@test.com", MessageID: tt.messageID, BounceType: bounce.BounceHard,
+			}})
+			require.NoError(t, err)
+
+			require.Equal(t, tt.wantStatus, deliveries.statuses[tt.messageID])
+			require.Equal(t, memorySuppressions{tt.wantTenant: {"client@test.com"}}, suppressions)
+			require.Len(t, publisher.events, 1)
+			require.Equal(t, tt.wantTenant, publisher.events[0].TenantID)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package bounce
+
+import (
+	"context"
+	"vodeno/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedService is a Service which records span of every call.
+type tracedService struct {
+	next Service
+}
+
+// NewTracedService wraps svc to record span of every call.
+func NewTracedService(svc Service) Service {
+	return tracedService{next: svc}
+}
+
+func (s tracedService) Process(ctx context.Context, events []Event) error {
+	ctx, span := tracing.Start(ctx, "bounce.Service.Process", trace.WithAttributes(attribute.Int("events", len(events))))
+	err := s.next.Process(ctx, events)
+	tracing.End(span, err)
+	return err
+}
@@ -0,0 +1,24 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of errors returned by Service. Errors are wrapped, so they must be checked with errors.Is.
+// Handler maps them to HTTP statuses, see problemFor.
+var (
+	// ErrNotFound is returned when requested Entry doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when request conflicts with stored data.
+	ErrConflict = errors.New("conflict")
+	// ErrValidation is returned when request is well-formed but can't be accepted.
+	ErrValidation = errors.New("validation failed")
+)
+
+var (
+	// ErrDuplicate is returned when Entry with the same payload already exists.
+	ErrDuplicate = fmt.Errorf("%w: entry with given payload already exists", ErrConflict)
+	// ErrSuppressed is returned when Entry's email is on suppression list.
+	ErrSuppressed = fmt.Errorf("%w: email is on suppression list", ErrValidation)
+)
@@ -1,17 +1,26 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"strconv"
+	"time"
+	"vodeno/pkg/audit"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/httpjson"
+	"vodeno/pkg/middleware"
+	"vodeno/pkg/problem"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator"
 	"github.com/sirupsen/logrus"
 )
 
+// maxAttachmentBodySize limits size of add attachment request, data is base64 encoded in it.
+const maxAttachmentBodySize = 32 << 20
+
 // Handler is a http handler for clients.
 type Handler struct {
 	service   Service
@@ -23,19 +32,20 @@ type Handler struct {
 func NewHandler(log *logrus.Logger, svc Service) *Handler {
 	return &Handler{
 		service:   svc,
-		validator: validator.New(),
+		validator: problem.NewValidator(),
 		log:       log,
 	}
 }
 
-// AddRoutes adds client routes to router.
+// AddRoutes adds client routes to router. Each route requires a scope.
 func (h *Handler) AddRoutes(router chi.Router) {
 	router.Route("/clients", func(r chi.Router) {
-		r.Post("/", h.add)
-		r.Post("/send", h.send)
-		r.Delete("/{id}", h.delete)
-		r.Get("/", h.list)
-		r.Get("/{id}", h.get)
+		r.With(middleware.RequireScope(auth.ScopeClientsWrite)).Post("/", h.add)
+		r.With(middleware.RequireScope(auth.ScopeMailingsSend)).Post("/send", h.send)
+		r.With(middleware.RequireScope(auth.ScopeClientsWrite)).Post("/attachments", h.addAttachment)
+		r.With(middleware.RequireScope(auth.ScopeClientsWrite)).Delete("/{id}", h.delete)
+		r.With(middleware.RequireScope(auth.ScopeClientsRead)).Get("/", h.list)
+		r.With(middleware.RequireScope(auth.ScopeClientsRead)).Get("/{id}", h.get)
 	})
 }
 
@@ -43,31 +53,25 @@ func (h *Handler) AddRoutes(router chi.Router) {
 func (h *Handler) add(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	logger := h.log.WithField("handler", "add")
+	logger := h.log.WithContext(ctx).WithField("handler", "add")
 	var req Entry
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := httpjson.Decode(r, &req); err != nil {
 		logger.WithError(err).Error("failed to decode request")
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, r, httpjson.Problem(err))
 		return
 	}
 
 	if err := h.validator.Struct(req); err != nil {
 		logger.WithError(err).Error("request is not valid")
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, r, problem.Validation(err))
 		return
 	}
 
+	audit.AddTarget(ctx, "mailing_id", req.MailingID)
 	if err := h.service.Add(ctx, req); err != nil {
 		logger.WithError(err).Error("failed to add client")
-		if errors.Is(err, ErrDuplicate) { // handle duplicate error.
-			h.writeJSONContentHeader(w)
-			w.WriteHeader(http.StatusBadRequest)
-			if _, err := w.Write([]byte(fmt.Sprintf(`{"error": "%s"}`, err))); err != nil {
-				h.log.WithError(err).Error("failed to write error to ResponseWriter")
-			}
-		}
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, r, problemFor(err))
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -82,25 +86,58 @@ type SendRequest struct {
 func (h *Handler) send(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	logger := h.log.WithField("handler", "send")
+	logger := h.log.WithContext(ctx).WithField("handler", "send")
 	var req SendRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := httpjson.Decode(r, &req); err != nil {
 		logger.WithError(err).Error("failed to decode request")
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, r, httpjson.Problem(err))
 		return
 	}
-	logger = h.log.WithField("mailing_id", req.MailingID)
+	logger = logger.WithField("mailing_id", req.MailingID)
 
 	if err := h.validator.Struct(req); err != nil {
 		logger.WithError(err).Error("request is not valid")
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, r, problem.Validation(err))
 		return
 	}
 
+	audit.AddTarget(ctx, "mailing_id", req.MailingID)
 	if err := h.service.Send(ctx, req.MailingID); err != nil {
 		logger.WithError(err).Error("failed to send emails")
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, r, problemFor(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// addAttachment gets Attachment from http request and calls Service for creation.
+func (h *Handler) addAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "addAttachment")
+	var req Attachment
+
+	if err := httpjson.DecodeLimit(r, &req, maxAttachmentBodySize); err != nil {
+		logger.WithError(err).Error("failed to decode request")
+		problem.Write(w, r, httpjson.Problem(err))
+		return
+	}
+	logger = logger.WithField("mailing_id", req.MailingID)
+
+	if err := h.validator.Struct(req); err != nil {
+		logger.WithError(err).Error("request is not valid")
+		problem.Write(w, r, problem.Validation(err))
+		return
+	}
+	if req.InsertTime.IsZero() {
+		req.InsertTime = time.Now()
+	}
+
+	audit.AddTarget(ctx, "mailing_id", req.MailingID)
+	if err := h.service.AddAttachment(ctx, req); err != nil {
+		logger.WithError(err).Error("failed to add attachment")
+		problem.Write(w, r, problemFor(err))
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -110,27 +147,27 @@ func (h *Handler) send(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	logger := h.log.WithField("handler", "delete")
+	logger := h.log.WithContext(ctx).WithField("handler", "delete")
 
 	clientID := chi.URLParam(r, "id")
 	logger = logger.WithField("client_id", clientID)
 
 	if clientID == "" {
 		logger.Error("clientID is empty")
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidParameter, "id is required"))
 		return
 	}
 
 	id, err := strconv.Atoi(clientID)
 	if err != nil {
 		logger.Error("clientID must be an integer")
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, r, problem.InvalidParameter("id", err))
 		return
 	}
 
 	if err := h.service.Delete(ctx, id); err != nil {
-		logger.WithError(err).Error("failed to send emails")
-		w.WriteHeader(http.StatusInternalServerError)
+		logger.WithError(err).Error("failed to delete client")
+		problem.Write(w, r, problemFor(err))
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -143,18 +180,18 @@ type listResponse struct {
 func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	logger := h.log.WithField("handler", "list")
+	logger := h.log.WithContext(ctx).WithField("handler", "list")
 
 	cursor, err := CursorFromRequest(r)
 	if err != nil {
 		logger.WithError(err).Error("failed to get cursor")
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidParameter, err.Error()))
 		return
 	}
 	clients, err := h.service.List(ctx, *cursor)
 	if err != nil {
 		logger.WithError(err).Error("failed to get clients")
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, r, problemFor(err))
 		return
 	}
 	if len(clients) == 0 {
@@ -177,35 +214,30 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	logger := h.log.WithField("handler", "get")
+	logger := h.log.WithContext(ctx).WithField("handler", "get")
 
 	clientID := chi.URLParam(r, "id")
 	logger = logger.WithField("client_id", clientID)
 
 	if clientID == "" {
 		logger.Error("clientID is empty")
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidParameter, "id is required"))
 		return
 	}
 
 	id, err := strconv.Atoi(clientID)
 	if err != nil {
 		logger.Error("clientID must be an integer")
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, r, problem.InvalidParameter("id", err))
 		return
 	}
 
 	client, err := h.service.Get(ctx, id)
 	if err != nil {
 		logger.WithError(err).Error("failed to get client")
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, r, problemFor(err))
 		return
 	}
-	if client == nil {
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
-
 	h.writeJSONContentHeader(w)
 	w.WriteHeader(http.StatusOK)
 	// write json.
@@ -214,6 +246,26 @@ func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// problemFor maps error returned by Service to Problem. Unknown errors are internal.
+func problemFor(err error) *problem.Problem {
+	switch {
+	case errors.Is(err, ErrDuplicate):
+		return problem.New(http.StatusConflict, problem.CodeDuplicate, err.Error())
+	case errors.Is(err, ErrSuppressed):
+		return problem.New(http.StatusBadRequest, problem.CodeSuppressed, err.Error())
+	case errors.Is(err, ErrNotFound):
+		return problem.New(http.StatusNotFound, problem.CodeNotFound, err.Error())
+	case errors.Is(err, ErrConflict):
+		return problem.New(http.StatusConflict, problem.CodeConflict, err.Error())
+	case errors.Is(err, ErrValidation):
+		return problem.New(http.StatusBadRequest, problem.CodeValidationFailed, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return problem.Timeout()
+	default:
+		return problem.Internal()
+	}
+}
+
 func (h Handler) writeJSONContentHeader(w http.ResponseWriter) {
 	w.Header().Add("Content-Type", "application/json; charset=utf-8")
 }
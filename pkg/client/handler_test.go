@@ -2,15 +2,19 @@ package client_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
+	"vodeno/pkg/auth"
 	"vodeno/pkg/client"
 	"vodeno/pkg/mocks"
+	"vodeno/pkg/problem"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/golang/mock/gomock"
@@ -18,9 +22,15 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-// newTestServer returns new test server and router.
-func newTestServer() (*httptest.Server, chi.Router) {
+// newTestServer returns new test server and router. Requests are made by a Principal with given scopes.
+func newTestServer(scopes ...auth.Scope) (*httptest.Server, chi.Router) {
 	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := &auth.Principal{ID: "apikey:1", Name: "test", Scopes: scopes}
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+		})
+	})
 
 	server := httptest.NewServer(router)
 	return server, router
@@ -40,13 +50,14 @@ func TestHandler_addRoute(t *testing.T) {
 	log := logrus.New()
 	log.Out = io.Discard
 
-	t0 := time.Now().Truncate(time.Second)
+	t0 := time.Now().UTC().Truncate(time.Second)
 
 	for _, tt := range []struct {
 		name         string
 		request      map[string]interface{}
 		prep         func(service *mocks.MockService)
 		wantedStatus int
+		wantedCode   string
 	}{
 		{
 			name: "Basic",
@@ -80,6 +91,7 @@ func TestHandler_addRoute(t *testing.T) {
 			},
 			prep:         func(mock *mocks.MockService) {},
 			wantedStatus: http.StatusBadRequest,
+			wantedCode:   problem.CodeValidationFailed,
 		},
 		{
 			name: "Returns400OnMissingEmail",
@@ -140,7 +152,7 @@ func TestHandler_addRoute(t *testing.T) {
 			wantedStatus: http.StatusBadRequest,
 		},
 		{
-			name: "Returns400OnDuplicate",
+			name: "Returns409OnDuplicate",
 			request: map[string]interface{}{
 				"email":       "email@test.com",
 				"title":       "title",
@@ -158,7 +170,8 @@ func TestHandler_addRoute(t *testing.T) {
 					InsertTime: t0,
 				}).Return(client.ErrDuplicate)
 			},
-			wantedStatus: http.StatusBadRequest,
+			wantedStatus: http.StatusConflict,
+			wantedCode:   problem.CodeDuplicate,
 		},
 		{
 			name: "Returns400InvalidRequest",
@@ -168,9 +181,22 @@ func TestHandler_addRoute(t *testing.T) {
 			prep:         func(mock *mocks.MockService) {},
 			wantedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "Returns400OnUnknownField",
+			request: map[string]interface{}{
+				"email":       "email@test.com",
+				"title":       "title",
+				"content":     "content",
+				"mailingId":   1,
+				"insert_time": t0,
+			},
+			prep:         func(mock *mocks.MockService) {},
+			wantedStatus: http.StatusBadRequest,
+			wantedCode:   problem.CodeInvalidJSON,
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			server, router := newTestServer()
+			server, router := newTestServer(auth.ScopeClientsWrite)
 			defer server.Close()
 
 			ctrl := gomock.NewController(t)
@@ -189,7 +215,14 @@ func TestHandler_addRoute(t *testing.T) {
 				bytes.NewReader(b),
 			)
 			require.NoError(t, err)
+			defer resp.Body.Close()
 			require.Equal(t, tt.wantedStatus, resp.StatusCode)
+
+			if tt.wantedCode != "" {
+				var body problem.Problem
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				require.Equal(t, tt.wantedCode, body.Code)
+			}
 		})
 	}
 }
@@ -238,7 +271,7 @@ func TestHandler_listRoute(t *testing.T) {
 		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			server, router := newTestServer()
+			server, router := newTestServer(auth.ScopeClientsRead)
 			defer server.Close()
 
 			ctrl := gomock.NewController(t)
@@ -254,3 +287,177 @@ func TestHandler_listRoute(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_getAndDeleteRoutes(t *testing.T) {
+	log := logrus.New()
+	log.Out = io.Discard
+
+	for _, tt := range []struct {
+		name         string
+		method       string
+		path         string
+		prep         func(service *mocks.MockService)
+		wantedStatus int
+		wantedCode   string
+	}{
+		{
+			name:   "GetReturns200",
+			method: http.MethodGet,
+			path:   "/clients/1",
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Get(gomock.Any(), 1).Return(&client.Entry{ID: 1}, nil)
+			},
+			wantedStatus: http.StatusOK,
+		},
+		{
+			name:   "GetReturns404OnMissingEntry",
+			method: http.MethodGet,
+			path:   "/clients/1",
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Get(gomock.Any(), 1).Return(nil, fmt.Errorf("entry 1: %w", client.ErrNotFound))
+			},
+			wantedStatus: http.StatusNotFound,
+			wantedCode:   problem.CodeNotFound,
+		},
+		{
+			name:         "GetReturns400OnInvalidID",
+			method:       http.MethodGet,
+			path:         "/clients/abc",
+			prep:         func(mock *mocks.MockService) {},
+			wantedStatus: http.StatusBadRequest,
+			wantedCode:   problem.CodeInvalidParameter,
+		},
+		{
+			name:   "DeleteReturns204",
+			method: http.MethodDelete,
+			path:   "/clients/1",
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Delete(gomock.Any(), 1)
+			},
+			wantedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "DeleteReturns404OnMissingEntry",
+			method: http.MethodDelete,
+			path:   "/clients/1",
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Delete(gomock.Any(), 1).Return(fmt.Errorf("entry 1: %w", client.ErrNotFound))
+			},
+			wantedStatus: http.StatusNotFound,
+			wantedCode:   problem.CodeNotFound,
+		},
+		{
+			name:   "DeleteReturns500OnUnknownError",
+			method: http.MethodDelete,
+			path:   "/clients/1",
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Delete(gomock.Any(), 1).Return(errors.New("connection refused"))
+			},
+			wantedStatus: http.StatusInternalServerError,
+			wantedCode:   problem.CodeInternal,
+		},
+		{
+			name:   "DeleteReturns504OnTimeout",
+			method: http.MethodDelete,
+			path:   "/clients/1",
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Delete(gomock.Any(), 1).Return(fmt.Errorf("delete: %w", context.DeadlineExceeded))
+			},
+			wantedStatus: http.StatusGatewayTimeout,
+			wantedCode:   problem.CodeTimeout,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server, router := newTestServer(auth.ScopeClientsRead, auth.ScopeClientsWrite)
+			defer server.Close()
+
+			ctrl := gomock.NewController(t)
+			mock := mocks.NewMockService(ctrl)
+
+			tt.prep(mock)
+			handler := client.NewHandler(log, mock)
+			handler.AddRoutes(router)
+
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, tt.wantedStatus, resp.StatusCode)
+
+			if tt.wantedCode != "" {
+				var body problem.Problem
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				require.Equal(t, tt.wantedCode, body.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_scopes(t *testing.T) {
+	log := logrus.New()
+	log.Out = io.Discard
+
+	for _, tt := range []struct {
+		name         string
+		scopes       []auth.Scope
+		method       string
+		path         string
+		prep         func(service *mocks.MockService)
+		wantedStatus int
+	}{
+		{
+			name:         "Returns403WithoutWriteScope",
+			scopes:       []auth.Scope{auth.ScopeClientsRead},
+			method:       http.MethodDelete,
+			path:         "/clients/1",
+			prep:         func(mock *mocks.MockService) {},
+			wantedStatus: http.StatusForbidden,
+		},
+		{
+			name:         "Returns403WithoutSendScope",
+			scopes:       []auth.Scope{auth.ScopeClientsWrite},
+			method:       http.MethodPost,
+			path:         "/clients/send",
+			prep:         func(mock *mocks.MockService) {},
+			wantedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "AdminHasAllScopes",
+			scopes: []auth.Scope{auth.ScopeAdmin},
+			method: http.MethodDelete,
+			path:   "/clients/1",
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Delete(gomock.Any(), 1)
+			},
+			wantedStatus: http.StatusNoContent,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server, router := newTestServer(tt.scopes...)
+			defer server.Close()
+
+			ctrl := gomock.NewController(t)
+			mock := mocks.NewMockService(ctrl)
+
+			tt.prep(mock)
+			handler := client.NewHandler(log, mock)
+			handler.AddRoutes(router)
+
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, tt.wantedStatus, resp.StatusCode)
+
+			if tt.wantedStatus == http.StatusForbidden {
+				require.Equal(t, problem.ContentType, resp.Header.Get("Content-Type"))
+				var body problem.Problem
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				require.Equal(t, problem.CodeForbidden, body.Code)
+				require.Contains(t, body.Detail, "missing required scope")
+			}
+		})
+	}
+}
@@ -5,9 +5,48 @@ import "time"
 // Entry represent client entry.
 type Entry struct {
 	ID         int       `json:"id" db:"id"`
+	TenantID   string    `json:"-" db:"tenant_id"` // set by Repository from tenant in context.
 	Email      string    `json:"email" db:"email" validate:"required,email"`
 	Title      string    `json:"title" db:"title" validate:"required"`
 	Content    string    `json:"content" db:"content" validate:"required"`
 	MailingID  int       `json:"mailing_id" db:"mailing_id" validate:"required"`
 	InsertTime time.Time `json:"insert_time" db:"insert_time" validate:"required"`
 }
+
+// Attachment represents a file attached to every message of a mailing.
+type Attachment struct {
+	ID          int    `json:"id" db:"id"`
+	TenantID    string `json:"-" db:"tenant_id"`
+	MailingID   int    `json:"mailing_id" db:"mailing_id" validate:"required"`
+	Filename    string `json:"filename" db:"filename" validate:"required"`
+	ContentType string `json:"content_type" db:"content_type" validate:"required"`
+	// ContentID makes attachment inline. It can be referenced from Entry's content
+	// with cid: URL, e.g. <img src="cid:logo">.
+	ContentID  string    `json:"content_id,omitempty" db:"content_id"`
+	Data       []byte    `json:"data" db:"data" validate:"required"`
+	InsertTime time.Time `json:"insert_time" db:"insert_time"`
+}
+
+// DeliveryStatus is a status of a sent message.
+type DeliveryStatus string
+
+const (
+	DeliverySent       DeliveryStatus = "sent"       // message was accepted by SMTP server.
+	DeliveryBounced    DeliveryStatus = "bounced"    // message was bounced by recipient's server.
+	DeliveryComplained DeliveryStatus = "complained" // recipient marked message as spam.
+)
+
+// Delivery represents a message sent to Entry's email.
+type Delivery struct {
+	ID        int            `json:"id" db:"id"`
+	TenantID  string         `json:"-" db:"tenant_id"`
+	MailingID int            `json:"mailing_id" db:"mailing_id"`
+	EntryID   int            `json:"entry_id" db:"entry_id"`
+	Email     string         `json:"email" db:"email"`
+	MessageID string         `json:"message_id" db:"message_id"`
+	Status    DeliveryStatus `json:"status" db:"status"`
+	// Detail describes last status change, e.g. bounce diagnostic code.
+	Detail     string    `json:"detail" db:"detail"`
+	InsertTime time.Time `json:"insert_time" db:"insert_time"`
+	UpdateTime time.Time `json:"update_time" db:"update_time"`
+}
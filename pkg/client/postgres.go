@@ -4,118 +4,290 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/db"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
 )
 
 const (
-	tableName = "entry" // client table name.
+	tableName           = "entry"      // client table name.
+	attachmentTableName = "attachment" // attachment table name.
+	deliveryTableName   = "delivery"   // delivery table name.
 
 	duplicateErrorCode = "23505"
 	doesNotExistCode   = "42P01"
 )
 
-var (
-	psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-
-	ErrDuplicate = errors.New("entry with given payload already exists")
-)
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
 // repo is postgresql implementation of Repository.
+//
+// Every query, except DeleteExpired and DeliveryTenant, is scoped by tenant from context. Queries filter
+// by tenant_id and run in a transaction as db.TenantRole, so row-level security hides
+// rows of other tenants even if a condition is missing.
 type repo struct {
-	db *sqlx.DB
+	db  *sqlx.DB
+	log *logrus.Entry
 }
 
 // NewRepo creates new instance of repo.
-func NewRepo(db *sqlx.DB) *repo {
-	return &repo{db: db}
+func NewRepo(logger *logrus.Logger, db *sqlx.DB) *repo {
+	return &repo{
+		db:  db,
+		log: logger.WithField("place", "client_repo"),
+	}
 }
 
-func (r repo) Insert(ctx context.Context, c Entry) error {
-	q := psql.Insert(tableName).
-		Columns("email", "title", "content", "mailing_id", "insert_time").
-		Values(c.Email, c.Title, c.Content, c.MailingID, c.InsertTime)
-
-	query, args, err := q.ToSql()
-	if err != nil {
-		return err
+// inTenant runs fn in a transaction restricted to tenant from context.
+func (r repo) inTenant(ctx context.Context, fn func(tx *sqlx.Tx, tenantID string) error) error {
+	tenantID, ok := auth.TenantFromContext(ctx)
+	if !ok {
+		return auth.ErrNoTenant
 	}
-	_, err = r.db.ExecContext(ctx, query, args...)
-	if err != nil {
-		pqErr, ok := err.(*pq.Error)
-		if ok {
-			if pqErr.Code == duplicateErrorCode {
-				return ErrDuplicate
+
+	return db.InTenant(ctx, r.db, tenantID, func(tx *sqlx.Tx) error {
+		return fn(tx, tenantID)
+	})
+}
+
+func (r repo) Insert(ctx context.Context, c Entry) (int, error) {
+	var id int
+	err := r.inTenant(ctx, func(tx *sqlx.Tx, tenantID string) error {
+		q := psql.Insert(tableName).
+			Columns("tenant_id", "email", "title", "content", "mailing_id", "insert_time").
+			Values(tenantID, c.Email, c.Title, c.Content, c.MailingID, c.InsertTime).
+			Suffix("RETURNING id")
+
+		query, args, err := q.ToSql()
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowxContext(ctx, query, args...).Scan(&id)
+		if err != nil {
+			pqErr, ok := err.(*pq.Error)
+			if ok {
+				if pqErr.Code == duplicateErrorCode {
+					return ErrDuplicate
+				}
 			}
+			return err
 		}
-		return err
-	}
-	return nil
+		return nil
+	})
+	return id, err
 }
 
-func (r repo) Delete(ctx context.Context, id int) error {
-	q := psql.Delete(tableName).Where(sq.Eq{"id": id})
-	query, args, err := q.ToSql()
-	if err != nil {
-		return err
-	}
-	_, err = r.db.ExecContext(ctx, query, args...)
-	return err
+func (r repo) Delete(ctx context.Context, id int) (*Entry, error) {
+	var deleted *Entry
+	err := r.inTenant(ctx, func(tx *sqlx.Tx, tenantID string) error {
+		q := psql.Delete(tableName).Where(sq.Eq{"tenant_id": tenantID, "id": id}).Suffix("RETURNING *")
+		query, args, err := q.ToSql()
+		if err != nil {
+			return err
+		}
+		var c Entry
+		if err := tx.GetContext(ctx, &c, query, args...); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+		deleted = &c
+		return nil
+	})
+	return deleted, err
 }
 
 func (r repo) BatchDelete(ctx context.Context, ids []int) error {
-	q := psql.Delete(tableName).Where(sq.Eq{"id": ids})
+	return r.inTenant(ctx, func(tx *sqlx.Tx, tenantID string) error {
+		q := psql.Delete(tableName).Where(sq.Eq{"tenant_id": tenantID, "id": ids})
+		query, args, err := q.ToSql()
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, query, args...)
+		return err
+	})
+}
+
+func (r repo) DeleteExpired(ctx context.Context, insertTimeLt time.Time) ([]Entry, error) {
+	q := psql.Delete(tableName).Where(sq.Lt{"insert_time": insertTimeLt}).
+		Suffix("RETURNING id, tenant_id, email, mailing_id, insert_time")
 	query, args, err := q.ToSql()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_, err = r.db.ExecContext(ctx, query, args...)
-	return err
+	var entries []Entry
+	if err := r.db.SelectContext(ctx, &entries, query, args...); err != nil {
+		return nil, err
+	}
+	return entries, nil
 }
 
 func (r repo) GetFilter(ctx context.Context, params *getParams) ([]Entry, error) {
-	q := psql.Select("*").From(tableName).OrderBy("id")
-	if params != nil { // set filtering.
-		if params.limit != nil {
-			q = q.Limit(uint64(*params.limit))
-		}
-		if params.offset != nil {
-			// we use where id > offset pagination. With create index it will be fast
-			// enough even with large amount of data.
-			// Another possible way of doing it is builtin postgresql cursor but it's overkill here.
-			// https://www.postgresql.org/docs/9.2/plpgsql-cursors.html
-			q = q.Where(sq.Gt{"id": *params.offset})
+	var clients []Entry
+	err := r.inTenant(ctx, func(tx *sqlx.Tx, tenantID string) error {
+		q := psql.Select("*").From(tableName).Where(sq.Eq{"tenant_id": tenantID}).OrderBy("id")
+		if params != nil { // set filtering.
+			if params.limit != nil {
+				q = q.Limit(uint64(*params.limit))
+			}
+			if params.offset != nil {
+				// we use where id > offset pagination. With create index it will be fast
+				// enough even with large amount of data.
+				// Another possible way of doing it is builtin postgresql cursor but it's overkill here.
+				// https://www.postgresql.org/docs/9.2/plpgsql-cursors.html
+				q = q.Where(sq.Gt{"id": *params.offset})
+			}
+			if params.mailingID != nil {
+				q = q.Where(sq.Eq{"mailing_id": *params.mailingID})
+			}
 		}
-		if params.mailingID != nil {
-			q = q.Where(sq.Eq{"mailing_id": *params.mailingID})
+		query, args, err := q.ToSql()
+		if err != nil {
+			return err
 		}
-	}
-	query, args, err := q.ToSql()
+		return tx.SelectContext(ctx, &clients, query, args...)
+	})
 	if err != nil {
 		return nil, err
 	}
-	var clients []Entry
-	if err := r.db.SelectContext(ctx, &clients, query, args...); err != nil {
-		return nil, err
-	}
 	return clients, nil
 }
 
 func (r repo) Get(ctx context.Context, id int) (*Entry, error) {
-	q := psql.Select("*").From(tableName).Where(sq.Eq{"id": id})
-	query, args, err := q.ToSql()
+	var client *Entry
+	err := r.inTenant(ctx, func(tx *sqlx.Tx, tenantID string) error {
+		q := psql.Select("*").From(tableName).Where(sq.Eq{"tenant_id": tenantID, "id": id})
+		query, args, err := q.ToSql()
+		if err != nil {
+			return err
+		}
+		var c Entry
+		if err := tx.GetContext(ctx, &c, query, args...); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("entry %d: %w", id, ErrNotFound)
+			}
+			return err
+		}
+		client = &c
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	var client Entry
-	if err := r.db.GetContext(ctx, &client, query, args...); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+	return client, nil
+}
+
+func (r repo) InsertAttachment(ctx context.Context, a Attachment) error {
+	return r.inTenant(ctx, func(tx *sqlx.Tx, tenantID string) error {
+		q := psql.Insert(attachmentTableName).
+			Columns("tenant_id", "mailing_id", "filename", "content_type", "content_id", "data", "insert_time").
+			Values(tenantID, a.MailingID, a.Filename, a.ContentType, a.ContentID, a.Data, a.InsertTime)
+
+		query, args, err := q.ToSql()
+		if err != nil {
+			return err
 		}
+		_, err = tx.ExecContext(ctx, query, args...)
+		return err
+	})
+}
+
+func (r repo) GetAttachments(ctx context.Context, mailingID int) ([]Attachment, error) {
+	var attachments []Attachment
+	err := r.inTenant(ctx, func(tx *sqlx.Tx, tenantID string) error {
+		q := psql.Select("*").From(attachmentTableName).
+			Where(sq.Eq{"tenant_id": tenantID, "mailing_id": mailingID}).
+			OrderBy("id")
+		query, args, err := q.ToSql()
+		if err != nil {
+			return err
+		}
+		return tx.SelectContext(ctx, &attachments, query, args...)
+	})
+	if err != nil {
 		return nil, err
 	}
+	return attachments, nil
+}
+
+func (r repo) InsertDelivery(ctx context.Context, d Delivery) error {
+	return r.inTenant(ctx, func(tx *sqlx.Tx, tenantID string) error {
+		q := psql.Insert(deliveryTableName).
+			Columns("tenant_id", "mailing_id", "entry_id", "email", "message_id", "status", "detail", "insert_time", "update_time").
+			Values(tenantID, d.MailingID, d.EntryID, strings.ToLower(d.Email), d.MessageID, d.Status, d.Detail, d.InsertTime, d.UpdateTime)
 
-	return &client, nil
+		query, args, err := q.ToSql()
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, query, args...)
+		return err
+	})
+}
+
+func (r repo) UpdateDeliveryStatus(
+	ctx context.Context, email, messageID string, status DeliveryStatus, detail string,
+) (bool, error) {
+	var updated bool
+	err := r.inTenant(ctx, func(tx *sqlx.Tx, tenantID string) error {
+		// latest matching delivery, nested query uses default placeholders which are replaced by outer one.
+		latest := sq.Select("id").From(deliveryTableName).
+			Where(sq.Eq{"tenant_id": tenantID, "email": strings.ToLower(email)}).
+			OrderBy("id DESC").
+			Limit(1)
+		if messageID != "" {
+			latest = latest.Where(sq.Eq{"message_id": messageID})
+		}
+
+		q := psql.Update(deliveryTableName).
+			Set("status", status).
+			Set("detail", detail).
+			Set("update_time", time.Now()).
+			Where(sq.Eq{"tenant_id": tenantID}).
+			Where(sq.Expr("id = (?)", latest))
+
+		query, args, err := q.ToSql()
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		updated = n > 0
+		return nil
+	})
+	return updated, err
+}
+
+func (r repo) DeliveryTenant(ctx context.Context, email, messageID string) (string, bool, error) {
+	q := psql.Select("tenant_id").From(deliveryTableName).
+		Where(sq.Eq{"email": strings.ToLower(email), "message_id": messageID}).
+		OrderBy("id DESC").
+		Limit(1)
+	query, args, err := q.ToSql()
+	if err != nil {
+		return "", false, err
+	}
+	var tenantID string
+	if err := r.db.GetContext(ctx, &tenantID, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return tenantID, true, nil
 }
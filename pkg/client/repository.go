@@ -8,23 +8,40 @@ import (
 // getParams is a container for GetFilter method filtering.
 // If needed we can add another filters.
 type getParams struct {
-	mailingID    *int
-	insertTimeLt *time.Time
-	offset       *int
-	limit        *int
+	mailingID *int
+	offset    *int
+	limit     *int
 }
 
 // Repository is a repository interface.
+// All methods except DeleteExpired and DeliveryTenant are scoped by tenant from context, see auth.TenantFromContext.
+// They return auth.ErrNoTenant if there is none.
 type Repository interface {
-	// Insert inserts Entry to storage.
-	Insert(ctx context.Context, c Entry) error
-	// Delete deletes Entry from storage.
-	Delete(ctx context.Context, id int) error
+	// Insert inserts Entry to storage and returns its ID.
+	Insert(ctx context.Context, c Entry) (int, error)
+	// Delete deletes Entry from storage and returns it. It returns nil if there is no such Entry.
+	Delete(ctx context.Context, id int) (*Entry, error)
 	// BatchDelete delete multiple Clients at once.
 	BatchDelete(ctx context.Context, ids []int) error
+	// DeleteExpired deletes Entries of all tenants inserted before given time.
+	// It returns deleted Entries without content.
+	DeleteExpired(ctx context.Context, insertTimeLt time.Time) ([]Entry, error)
 	// GetFilter gets Entries from storage.
 	// If params are nil it gets all Entries.
 	GetFilter(ctx context.Context, params *getParams) ([]Entry, error)
-	// Get queries single Entry.
+	// Get queries single Entry. It returns ErrNotFound if there is no such Entry.
 	Get(ctx context.Context, id int) (*Entry, error)
+	// InsertAttachment inserts Attachment to storage.
+	InsertAttachment(ctx context.Context, a Attachment) error
+	// GetAttachments gets all Attachments of a mailing.
+	GetAttachments(ctx context.Context, mailingID int) ([]Attachment, error)
+	// InsertDelivery inserts Delivery to storage.
+	InsertDelivery(ctx context.Context, d Delivery) error
+	// UpdateDeliveryStatus updates status of the latest Delivery to given email.
+	// If messageID is not empty, only Delivery of that message is updated.
+	// It returns false if there is no matching Delivery.
+	UpdateDeliveryStatus(ctx context.Context, email, messageID string, status DeliveryStatus, detail string) (bool, error)
+	// DeliveryTenant returns tenant of the latest Delivery of message to given email, in any tenant.
+	// It returns false if there is no such Delivery.
+	DeliveryTenant(ctx context.Context, email, messageID string) (string, bool, error)
 }
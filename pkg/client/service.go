@@ -1,6 +1,19 @@
 package client
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/textproto"
+	"strings"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/events"
+	"vodeno/pkg/mail"
+	"vodeno/pkg/metrics"
+
+	"github.com/sirupsen/logrus"
+)
 
 //go:generate mockgen -destination ../mocks/mock_service.go -package=mocks . Service
 
@@ -11,26 +24,79 @@ type Service interface {
 	// Send sends email to every Entry with given mailingID.
 	// After that, Clients with that mailingID will be removed from db.
 	Send(ctx context.Context, mailingID int) error
-	// Delete deletes Entry with given params.
+	// Delete deletes Entry with given params. It returns ErrNotFound if there is no such Entry.
 	Delete(ctx context.Context, id int) error
-	// Get gets single Entry base on id.
+	// Get gets single Entry base on id. It returns ErrNotFound if there is no such Entry.
 	Get(ctx context.Context, id int) (*Entry, error)
 	// List lists Clients with pagination.
 	List(ctx context.Context, cursor Cursor) ([]Entry, error)
+	// AddAttachment adds Attachment to every message of a mailing.
+	AddAttachment(ctx context.Context, attachment Attachment) error
+	// UpdateDelivery updates status of a message sent to email, e.g. when it bounced.
+	// If messageID is empty, the latest message sent to email is updated.
+	// It returns false if there is no such message.
+	UpdateDelivery(ctx context.Context, email, messageID string, status DeliveryStatus, detail string) (bool, error)
+	// DeliveryTenant returns tenant which sent message with given ID to email. It isn't scoped by tenant,
+	// so bounce reports can be attributed to the tenant of the message rather than of the reporter.
+	// It returns false if there is no such message.
+	DeliveryTenant(ctx context.Context, email, messageID string) (string, bool, error)
+}
+
+// SuppressionList is a list of addresses which must not be mailed.
+type SuppressionList interface {
+	// Suppressed returns set of given emails which are suppressed.
+	// Returned emails are lower-cased.
+	Suppressed(ctx context.Context, emails ...string) (map[string]bool, error)
+	// UnsubscribeURL returns signed one-click unsubscribe link for given tenant and email.
+	UnsubscribeURL(tenantID, email string) string
 }
 
 // service implements Service interface.
 type service struct {
-	repository Repository
+	log          *logrus.Entry
+	repository   Repository
+	sender       mail.Sender
+	suppressions SuppressionList
+	events       events.Publisher
+	// rejectSuppressed makes Add fail for suppressed addresses.
+	rejectSuppressed bool
 }
 
 // NewService returns new Service.
-func NewService(repository Repository) Service {
-	return service{repository: repository}
+func NewService(
+	logger *logrus.Logger,
+	repository Repository,
+	sender mail.Sender,
+	suppressions SuppressionList,
+	publisher events.Publisher,
+	rejectSuppressed bool,
+) Service {
+	return service{
+		log:              logger.WithField("place", "client"),
+		repository:       repository,
+		sender:           sender,
+		suppressions:     suppressions,
+		events:           publisher,
+		rejectSuppressed: rejectSuppressed,
+	}
 }
 
 func (s service) Add(ctx context.Context, client Entry) error {
-	return s.repository.Insert(ctx, client)
+	if s.rejectSuppressed {
+		suppressed, err := s.suppressions.Suppressed(ctx, client.Email)
+		if err != nil {
+			return err
+		}
+		if suppressed[strings.ToLower(client.Email)] {
+			return ErrSuppressed
+		}
+	}
+	id, err := s.repository.Insert(ctx, client)
+	if err != nil {
+		return err
+	}
+	s.publish(ctx, events.Event{Type: events.EntryCreated, MailingID: client.MailingID, EntryID: id})
+	return nil
 }
 
 func (s service) Send(ctx context.Context, mailingID int) error {
@@ -38,19 +104,85 @@ func (s service) Send(ctx context.Context, mailingID int) error {
 	if err != nil {
 		return err
 	}
+	attachments, err := s.repository.GetAttachments(ctx, mailingID)
+	if err != nil {
+		return err
+	}
 
-	// Do the mailing things. Loop through all of clients and send emails using some kind of interface dependency.
+	emails := make([]string, 0, len(clients))
+	for _, c := range clients {
+		emails = append(emails, c.Email)
+	}
+	suppressed, err := s.suppressions.Suppressed(ctx, emails...)
+	if err != nil {
+		return err
+	}
 
-	ids := make([]int, 0)
+	// Only successfully sent and suppressed Clients are removed, the rest can be sent again.
+	var (
+		ids                   = make([]int, 0, len(clients))
+		sendErr               error
+		sent, skipped, failed int
+	)
+	logger := s.log.WithContext(ctx).WithField("mailing_id", mailingID)
+	s.publish(ctx, events.Event{Type: events.SendStarted, MailingID: mailingID, Entries: len(clients)})
 	for _, c := range clients {
+		if suppressed[strings.ToLower(c.Email)] {
+			logger.WithField("client_id", c.ID).Debug("skipping suppressed email")
+			metrics.CountMessage(metrics.OutcomeSuppressed)
+			ids = append(ids, c.ID)
+			skipped++
+			continue
+		}
+		msg := s.newMessage(c, attachments)
+		if err := s.sender.Send(ctx, msg); err != nil {
+			logger.WithError(err).WithField("client_id", c.ID).Warn("failed to send email")
+			metrics.CountMessage(metrics.OutcomeFailed)
+			sendErr = err
+			failed++
+			continue
+		}
 		ids = append(ids, c.ID)
+		sent++
+		metrics.CountMessage(metrics.OutcomeSent)
+		s.publish(ctx, events.Event{Type: events.MessageSent, MailingID: mailingID, EntryID: c.ID})
+
+		now := time.Now()
+		if err := s.repository.InsertDelivery(ctx, Delivery{
+			MailingID:  c.MailingID,
+			EntryID:    c.ID,
+			Email:      c.Email,
+			MessageID:  msg.Header.Get("Message-Id"),
+			Status:     DeliverySent,
+			InsertTime: now,
+			UpdateTime: now,
+		}); err != nil {
+			sendErr = err
+		}
 	}
 
-	return s.repository.BatchDelete(ctx, ids)
+	if err := s.repository.BatchDelete(ctx, ids); err != nil {
+		return err
+	}
+	s.publish(ctx, events.Event{
+		Type: events.SendCompleted, MailingID: mailingID, Sent: sent, Suppressed: skipped, Failed: failed,
+	})
+	if sendErr != nil {
+		return fmt.Errorf("failed to send %d of %d emails: %w", failed, len(clients), sendErr)
+	}
+	return nil
 }
 
 func (s service) Delete(ctx context.Context, id int) error {
-	return s.repository.Delete(ctx, id)
+	deleted, err := s.repository.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if deleted == nil {
+		return fmt.Errorf("entry %d: %w", id, ErrNotFound)
+	}
+	s.publish(ctx, events.Event{Type: events.EntryDeleted, MailingID: deleted.MailingID, EntryID: id})
+	return nil
 }
 
 func (s service) Get(ctx context.Context, id int) (*Entry, error) {
@@ -63,3 +195,66 @@ func (s service) List(ctx context.Context, cursor Cursor) ([]Entry, error) {
 		limit:  &cursor.Limit,
 	})
 }
+
+func (s service) AddAttachment(ctx context.Context, attachment Attachment) error {
+	return s.repository.InsertAttachment(ctx, attachment)
+}
+
+func (s service) UpdateDelivery(
+	ctx context.Context, email, messageID string, status DeliveryStatus, detail string,
+) (bool, error) {
+	return s.repository.UpdateDeliveryStatus(ctx, email, messageID, status, detail)
+}
+
+func (s service) DeliveryTenant(ctx context.Context, email, messageID string) (string, bool, error) {
+	return s.repository.DeliveryTenant(ctx, email, messageID)
+}
+
+// publish publishes event of tenant from context. Events only report progress,
+// so failure is logged and doesn't fail the operation.
+func (s service) publish(ctx context.Context, event events.Event) {
+	event.TenantID, _ = auth.TenantFromContext(ctx)
+	event.Time = time.Now()
+	if err := s.events.Publish(ctx, event); err != nil {
+		s.log.WithContext(ctx).WithError(err).WithField("type", event.Type).Warn("failed to publish event")
+	}
+}
+
+// newMessage creates mail.Message for Entry.
+// Entry's content is treated as HTML, plain-text alternative is generated from it.
+// Every message gets one-click unsubscribe link, RFC 8058.
+func (s service) newMessage(c Entry, attachments []Attachment) *mail.Message {
+	unsubscribeURL := s.suppressions.UnsubscribeURL(c.TenantID, c.Email)
+	msg := &mail.Message{
+		To:      []string{c.Email},
+		Subject: c.Title,
+		HTML:    withUnsubscribeLink(c.Content, unsubscribeURL),
+		Header: textproto.MIMEHeader{
+			"List-Unsubscribe":      {"<" + unsubscribeURL + ">"},
+			"List-Unsubscribe-Post": {"List-Unsubscribe=One-Click"},
+		},
+	}
+	for _, a := range attachments {
+		ma := mail.Attachment{
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			ContentID:   a.ContentID,
+			Data:        a.Data,
+		}
+		if a.ContentID != "" {
+			msg.Inline = append(msg.Inline, ma)
+		} else {
+			msg.Attachments = append(msg.Attachments, ma)
+		}
+	}
+	return msg
+}
+
+// withUnsubscribeLink appends unsubscribe link at the end of HTML body.
+func withUnsubscribeLink(content, unsubscribeURL string) string {
+	link := fmt.Sprintf(`<p><a href="%s">Unsubscribe</a></p>`, html.EscapeString(unsubscribeURL))
+	if i := strings.LastIndex(strings.ToLower(content), "</body>"); i >= 0 {
+		return content[:i] + link + content[i:]
+	}
+	return content + link
+}
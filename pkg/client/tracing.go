@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"vodeno/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedService is a Service which records span of every call.
+type tracedService struct {
+	next Service
+}
+
+// NewTracedService wraps svc to record span of every call.
+func NewTracedService(svc Service) Service {
+	return tracedService{next: svc}
+}
+
+func (s tracedService) Add(ctx context.Context, client Entry) error {
+	ctx, span := tracing.Start(ctx, "client.Service.Add", trace.WithAttributes(attribute.Int("mailing_id", client.MailingID)))
+	err := s.next.Add(ctx, client)
+	tracing.End(span, err)
+	return err
+}
+
+func (s tracedService) Send(ctx context.Context, mailingID int) error {
+	ctx, span := tracing.Start(ctx, "client.Service.Send", trace.WithAttributes(attribute.Int("mailing_id", mailingID)))
+	err := s.next.Send(ctx, mailingID)
+	tracing.End(span, err)
+	return err
+}
+
+func (s tracedService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "client.Service.Delete", trace.WithAttributes(attribute.Int("id", id)))
+	err := s.next.Delete(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (s tracedService) Get(ctx context.Context, id int) (*Entry, error) {
+	ctx, span := tracing.Start(ctx, "client.Service.Get", trace.WithAttributes(attribute.Int("id", id)))
+	entry, err := s.next.Get(ctx, id)
+	tracing.End(span, err)
+	return entry, err
+}
+
+func (s tracedService) List(ctx context.Context, cursor Cursor) ([]Entry, error) {
+	ctx, span := tracing.Start(ctx, "client.Service.List")
+	entries, err := s.next.List(ctx, cursor)
+	tracing.End(span, err)
+	return entries, err
+}
+
+func (s tracedService) AddAttachment(ctx context.Context, attachment Attachment) error {
+	ctx, span := tracing.Start(ctx, "client.Service.AddAttachment",
+		trace.WithAttributes(attribute.Int("mailing_id", attachment.MailingID)))
+	err := s.next.AddAttachment(ctx, attachment)
+	tracing.End(span, err)
+	return err
+}
+
+func (s tracedService) UpdateDelivery(
+	ctx context.Context, email, messageID string, status DeliveryStatus, detail string,
+) (bool, error) {
+	ctx, span := tracing.Start(ctx, "client.Service.UpdateDelivery",
+		trace.WithAttributes(attribute.String("status", string(status))))
+	updated, err := s.next.UpdateDelivery(ctx, email, messageID, status, detail)
+	tracing.End(span, err)
+	return updated, err
+}
+
+func (s tracedService) DeliveryTenant(ctx context.Context, email, messageID string) (string, bool, error) {
+	ctx, span := tracing.Start(ctx, "client.Service.DeliveryTenant")
+	tenantID, found, err := s.next.DeliveryTenant(ctx, email, messageID)
+	tracing.End(span, err)
+	return tenantID, found, err
+}
@@ -2,28 +2,43 @@ package client
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
+	"vodeno/pkg/events"
+	"vodeno/pkg/metrics"
 
 	"github.com/sirupsen/logrus"
 )
 
 var ttl = time.Minute * 5 // time for client entry to exist.
 
+// stuckTicks is a number of tick periods after which clearing in progress is considered stuck.
+const stuckTicks = 5
+
+// ErrWatcherStopped is returned by Watcher.Check when clearing goroutine isn't running.
+var ErrWatcherStopped = errors.New("watcher is not running")
+
 // Watcher is responsible for watching over old entries and deleting them.
 type Watcher struct {
 	tickPeriod time.Duration
 	log        logrus.FieldLogger
 	wg         sync.WaitGroup
 	repo       Repository
+	events     events.Publisher
 	close      chan struct{} // channel is used for graceful shutdown
+	lastTick   int64         // unix time in nanoseconds of the last tick, zero when stopped. Accessed atomically.
+	clearStart int64         // unix time in nanoseconds of start of clearing in progress, zero if none. Accessed atomically.
 }
 
 // NewWatcher create new instance of Watcher.
-func NewWatcher(logger *logrus.Logger, repo Repository, tp time.Duration) *Watcher {
+func NewWatcher(logger *logrus.Logger, repo Repository, publisher events.Publisher, tp time.Duration) *Watcher {
 	return &Watcher{
 		log:        logger.WithField("place", "watcher"),
 		repo:       repo,
+		events:     publisher,
 		close:      make(chan struct{}),
 		tickPeriod: tp,
 	}
@@ -33,16 +48,23 @@ func NewWatcher(logger *logrus.Logger, repo Repository, tp time.Duration) *Watch
 func (w *Watcher) Start(ctx context.Context) {
 	w.wg.Add(1)
 	w.log.WithField("tick", w.tickPeriod.String()).Info("starting")
+	atomic.StoreInt64(&w.lastTick, time.Now().UnixNano())
 	go func() {
 		defer w.wg.Done()
+		defer atomic.StoreInt64(&w.lastTick, 0)
 		ticker := time.NewTicker(w.tickPeriod)
 		for {
 			select {
-			case <-ticker.C:
+			case t := <-ticker.C:
+				atomic.StoreInt64(&w.lastTick, t.UnixNano())
+				atomic.StoreInt64(&w.clearStart, time.Now().UnixNano())
 				w.log.Info("clearing")
 				if err := w.clear(ctx); err != nil {
 					w.log.WithError(err).Error("failed to clear old client entries.")
 				}
+				// ticks missed by slow clearing are not missed by the watcher, it's ready for the next one.
+				atomic.StoreInt64(&w.lastTick, time.Now().UnixNano())
+				atomic.StoreInt64(&w.clearStart, 0)
 			case <-w.close:
 				w.log.Info("closing")
 				return
@@ -51,19 +73,48 @@ func (w *Watcher) Start(ctx context.Context) {
 	}()
 }
 
-// clear queries repository for entries to be deleted and deletes them.
+// clear deletes old entries of all tenants and publishes their entry.expired events.
 func (w *Watcher) clear(ctx context.Context) error {
-	t := time.Now().Add(-ttl)
-	clients, err := w.repo.GetFilter(ctx, &getParams{insertTimeLt: &t})
+	deleted, err := w.repo.DeleteExpired(ctx, time.Now().Add(-ttl))
+	metrics.ObserveWatcherRun(int64(len(deleted)), err)
 	if err != nil {
 		return err
 	}
+	w.log.WithField("deleted", len(deleted)).Info("cleared")
 
-	ids := make([]int, 0, len(clients))
-	for _, client := range clients {
-		ids = append(ids, client.ID)
+	now := time.Now()
+	expired := make([]events.Event, 0, len(deleted))
+	for _, e := range deleted {
+		expired = append(expired, events.Event{
+			Type: events.EntryExpired, TenantID: e.TenantID, MailingID: e.MailingID, EntryID: e.ID, Time: now,
+		})
+	}
+	if err := w.events.Publish(ctx, expired...); err != nil {
+		// entries are deleted anyway, only subscribers miss events.
+		w.log.WithError(err).Warn("failed to publish expired events")
+	}
+	return nil
+}
+
+// Check returns error if clearing goroutine is stopped, missed two ticks while idle or is clearing
+// for more than stuckTicks tick periods. Slow clearing alone doesn't fail the check.
+func (w *Watcher) Check(context.Context) error {
+	// clearStart is loaded first, lastTick is updated before it's reset at the end of clearing.
+	clearStart := atomic.LoadInt64(&w.clearStart)
+	lastTick := atomic.LoadInt64(&w.lastTick)
+	if lastTick == 0 {
+		return ErrWatcherStopped
+	}
+	if clearStart != 0 {
+		if since := time.Since(time.Unix(0, clearStart)); since > stuckTicks*w.tickPeriod {
+			return fmt.Errorf("watcher is clearing for %s", since.Round(time.Second))
+		}
+		return nil
+	}
+	if since := time.Since(time.Unix(0, lastTick)); since > 2*w.tickPeriod {
+		return fmt.Errorf("watcher hasn't ticked for %s", since.Round(time.Second))
 	}
-	return w.repo.BatchDelete(ctx, ids)
+	return nil
 }
 
 // Stop stops watcher and waits for goroutine to shutdown.
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -9,9 +10,145 @@ import (
 )
 
 type Config struct {
-	Port    int           `json:"port" mapstructure:"port"`
-	DB      DBConfig      `json:"db" mapstructure:"db"`
-	Watcher WatcherConfig `json:"watcher" mapstructure:"watcher"`
+	Port int `json:"port" mapstructure:"port"`
+	// AdminPort is a port of admin server exposing /metrics, it's not reachable through the API port.
+	AdminPort int `json:"admin_port" mapstructure:"admin_port"`
+	// GRPCPort is a port of gRPC server of internal services.
+	GRPCPort    int                `json:"grpc_port" mapstructure:"grpc_port"`
+	DB          DBConfig           `json:"db" mapstructure:"db"`
+	Watcher     WatcherConfig      `json:"watcher" mapstructure:"watcher"`
+	Mail        MailConfig         `json:"mail" mapstructure:"mail"`
+	Suppression SuppressionConfig  `json:"suppression" mapstructure:"suppression"`
+	Auth        AuthConfig         `json:"auth" mapstructure:"auth"`
+	Log         LogConfig          `json:"log" mapstructure:"log"`
+	Tracing     TracingConfig      `json:"tracing" mapstructure:"tracing"`
+	Health      HealthConfig       `json:"health" mapstructure:"health"`
+	OpenAPI     OpenAPIConfig      `json:"openapi" mapstructure:"openapi"`
+	Events      EventsConfig       `json:"events" mapstructure:"events"`
+	Webhook     WebhookConfig      `json:"webhook" mapstructure:"webhook"`
+	RateLimit   RequestLimitConfig `json:"rate_limit" mapstructure:"rate_limit"`
+	HTTP        HTTPConfig         `json:"http" mapstructure:"http"`
+}
+
+// HTTPConfig configures HTTP servers and deadlines of requests.
+type HTTPConfig struct {
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout are timeouts of http.Server, zero means no timeout.
+	ReadHeaderTimeout time.Duration `json:"read_header_timeout" mapstructure:"read_header_timeout"`
+	ReadTimeout       time.Duration `json:"read_timeout" mapstructure:"read_timeout"`
+	WriteTimeout      time.Duration `json:"write_timeout" mapstructure:"write_timeout"`
+	IdleTimeout       time.Duration `json:"idle_timeout" mapstructure:"idle_timeout"`
+	// RequestTimeout is a deadline of requests to routes not listed in Routes. Zero means no deadline.
+	RequestTimeout time.Duration        `json:"request_timeout" mapstructure:"request_timeout"`
+	Routes         []RouteTimeoutConfig `json:"routes" mapstructure:"routes"`
+	CORS           CORSConfig           `json:"cors" mapstructure:"cors"`
+}
+
+// RouteTimeoutConfig sets deadline of requests to a single route.
+type RouteTimeoutConfig struct {
+	// Route is a method and chi pattern of the route, e.g. "POST /clients/send" or "GET /clients/{id}".
+	Route string `json:"route" mapstructure:"route"`
+	// Timeout is a deadline of requests to the route. Zero means no deadline.
+	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+}
+
+// CORSConfig allows browser applications from other origins to call the API.
+type CORSConfig struct {
+	// AllowedOrigins are origins of the applications, e.g. https://admin.example.com, or * for any.
+	// CORS is disabled when it's empty.
+	AllowedOrigins []string `json:"allowed_origins" mapstructure:"allowed_origins"`
+	AllowedMethods []string `json:"allowed_methods" mapstructure:"allowed_methods"`
+	AllowedHeaders []string `json:"allowed_headers" mapstructure:"allowed_headers"`
+	// ExposedHeaders are response headers readable by the applications.
+	ExposedHeaders []string `json:"exposed_headers" mapstructure:"exposed_headers"`
+	// AllowCredentials allows requests with cookies or TLS client certificates.
+	// It can't be used with * origin, any site could make requests with the user's credentials.
+	AllowCredentials bool `json:"allow_credentials" mapstructure:"allow_credentials"`
+	// MaxAge is a time for which browsers cache preflight responses.
+	MaxAge time.Duration `json:"max_age" mapstructure:"max_age"`
+}
+
+// Validate returns error if AllowCredentials is used with * origin.
+func (c CORSConfig) Validate() error {
+	for _, o := range c.AllowedOrigins {
+		if o == "*" && c.AllowCredentials {
+			return errors.New("http.cors: allow_credentials can't be used with * allowed origin")
+		}
+	}
+	return nil
+}
+
+// RequestLimitConfig limits rate of API requests of every API key, or client IP of unauthenticated requests.
+type RequestLimitConfig struct {
+	// Store is memory, which limits every instance separately, or postgres, which shares limits by all instances.
+	Store string `json:"store" mapstructure:"store"`
+	// Default limits are used for route groups not listed in Groups.
+	Default RouteLimitConfig   `json:"default" mapstructure:"default"`
+	Groups  []RouteLimitConfig `json:"groups" mapstructure:"groups"`
+}
+
+// RouteLimitConfig limits requests to a group of routes, e.g. clients.
+type RouteLimitConfig struct {
+	Group string `json:"group" mapstructure:"group"`
+	// Rate is a number of requests per second. Zero means no limit.
+	Rate float64 `json:"rate" mapstructure:"rate"`
+	// Burst is a maximum number of requests sent at once, idle callers can send it before Rate applies.
+	Burst int `json:"burst" mapstructure:"burst"`
+}
+
+// WebhookConfig configures delivery of webhooks.
+type WebhookConfig struct {
+	// TickPeriod is a period of checking for pending deliveries.
+	TickPeriod time.Duration `json:"tick_period" mapstructure:"tick_period"`
+	// Timeout limits time of a single delivery attempt.
+	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+	// MaxAttempts is the number of attempts after which delivery fails.
+	MaxAttempts int `json:"max_attempts" mapstructure:"max_attempts"`
+	// Backoff is a delay after the first failed attempt, it doubles with every next one.
+	Backoff time.Duration `json:"backoff" mapstructure:"backoff"`
+	// BatchSize is the number of deliveries claimed at once.
+	BatchSize int `json:"batch_size" mapstructure:"batch_size"`
+}
+
+// EventsConfig configures stream of lifecycle events.
+type EventsConfig struct {
+	// KeepAlive is a period of comments sent to idle streams, so proxies don't close them.
+	KeepAlive time.Duration `json:"keep_alive" mapstructure:"keep_alive"`
+}
+
+// OpenAPIConfig configures serving of API specification.
+type OpenAPIConfig struct {
+	// Docs enables /docs page rendering the specification, /openapi.json is always served.
+	Docs bool `json:"docs" mapstructure:"docs"`
+}
+
+// HealthConfig configures readiness probe.
+type HealthConfig struct {
+	// CheckTimeout limits time of readiness checks, e.g. database ping.
+	CheckTimeout time.Duration `json:"check_timeout" mapstructure:"check_timeout"`
+	// ShutdownDelay is a time between reporting unready and stopping the server on shutdown,
+	// so load balancers stop sending new requests first.
+	ShutdownDelay time.Duration `json:"shutdown_delay" mapstructure:"shutdown_delay"`
+}
+
+// TracingConfig configures export of OpenTelemetry spans.
+type TracingConfig struct {
+	// Exporter is otlp, stdout or empty to disable recording of spans.
+	Exporter string `json:"exporter" mapstructure:"exporter"`
+	// Endpoint is a host:port of OTLP/HTTP collector, the default one is localhost:4318.
+	Endpoint string `json:"endpoint" mapstructure:"endpoint"`
+	// Insecure disables TLS of connection to collector.
+	Insecure bool `json:"insecure" mapstructure:"insecure"`
+	// ServiceName is a service.name of exported spans.
+	ServiceName string `json:"service_name" mapstructure:"service_name"`
+	// SampleRatio is a fraction of new traces which are recorded, traces from incoming requests follow caller's decision.
+	SampleRatio float64 `json:"sample_ratio" mapstructure:"sample_ratio"`
+}
+
+type LogConfig struct {
+	// Format is a format of log entries, json or text.
+	Format string `json:"format" mapstructure:"format"`
+	// Level is a minimal level of logged entries, e.g. info or debug.
+	Level string `json:"level" mapstructure:"level"`
 }
 
 type DBConfig struct {
@@ -22,14 +159,116 @@ type DBConfig struct {
 	Password            string `json:"password" mapstructure:"password"`
 	SSLEnabled          bool   `json:"ssl_enabled" mapstructure:"ssl_enabled"`
 	ConnMaxLifetimeSecs int    `json:"conn_max_lifetime_secs" mapstructure:"conn_max_lifetime_secs"`
-	MaxOpenConns        int    `json:"max_open_conns" mapstructure:"max_open_conns"`
-	MaxIdleConns        int    `json:"max_idle_conns" mapstructure:"max_idle_conns"`
+	// MaxOpenConns limits connections of the pool shared by API requests, watcher, webhook dispatcher,
+	// audit, postgres rate limit store and mail.rate_limit leases. Mailings are sent one message at a time
+	// within their request, so it should cover concurrent requests plus the watcher and the dispatcher;
+	// times instances it must stay below max_connections of the database.
+	MaxOpenConns int `json:"max_open_conns" mapstructure:"max_open_conns"`
+	MaxIdleConns int `json:"max_idle_conns" mapstructure:"max_idle_conns"`
 }
 
 type WatcherConfig struct {
 	TickPeriod time.Duration `json:"tick_period" mapstructure:"tick_period"`
 }
 
+type MailConfig struct {
+	From      string          `json:"from" mapstructure:"from"`
+	SMTP      SMTPConfig      `json:"smtp" mapstructure:"smtp"`
+	DKIM      DKIMConfig      `json:"dkim" mapstructure:"dkim"`
+	RateLimit RateLimitConfig `json:"rate_limit" mapstructure:"rate_limit"`
+}
+
+type SMTPConfig struct {
+	Host     string `json:"host" mapstructure:"host"`
+	Port     int    `json:"port" mapstructure:"port"`
+	Username string `json:"username" mapstructure:"username"`
+	Password string `json:"password" mapstructure:"password"`
+}
+
+type DKIMConfig struct {
+	// Headers is a default list of signed headers.
+	Headers []string           `json:"headers" mapstructure:"headers"`
+	Domains []DKIMDomainConfig `json:"domains" mapstructure:"domains"`
+}
+
+// DKIMDomainConfig is a DKIM signing configuration of a single sender domain.
+type DKIMDomainConfig struct {
+	Domain   string `json:"domain" mapstructure:"domain"`
+	Selector string `json:"selector" mapstructure:"selector"`
+	// KeyFile is a path to PEM encoded RSA or Ed25519 private key.
+	KeyFile string `json:"key_file" mapstructure:"key_file"`
+	// Key is PEM encoded private key, it's used when KeyFile is empty.
+	Key string `json:"key" mapstructure:"key"`
+	// Headers overrides DKIMConfig.Headers for the domain.
+	Headers []string `json:"headers" mapstructure:"headers"`
+}
+
+type SuppressionConfig struct {
+	// RejectOnAdd makes adding client entries with suppressed addresses fail.
+	RejectOnAdd bool              `json:"reject_on_add" mapstructure:"reject_on_add"`
+	Unsubscribe UnsubscribeConfig `json:"unsubscribe" mapstructure:"unsubscribe"`
+}
+
+type UnsubscribeConfig struct {
+	// BaseURL is a public URL of the service used to build unsubscribe links.
+	BaseURL string `json:"base_url" mapstructure:"base_url"`
+	// Secret is used to sign unsubscribe links.
+	Secret string `json:"secret" mapstructure:"secret"`
+}
+
+// RateLimitConfig limits sending of messages by all instances of the service.
+type RateLimitConfig struct {
+	// Global is a maximum number of messages sent per second. Zero means no limit.
+	Global int `json:"global" mapstructure:"global"`
+	// Default limits are used for recipient domains not listed in Domains.
+	Default DomainLimitConfig   `json:"default" mapstructure:"default"`
+	Domains []DomainLimitConfig `json:"domains" mapstructure:"domains"`
+	// LeaseTTL is a time after which concurrency slot of crashed instance is released.
+	// Slots of running sends are renewed every half of it.
+	LeaseTTL time.Duration `json:"lease_ttl" mapstructure:"lease_ttl"`
+}
+
+// DomainLimitConfig limits sending of messages to a single recipient domain.
+type DomainLimitConfig struct {
+	Domain string `json:"domain" mapstructure:"domain"`
+	// Rate is a maximum number of messages sent per second. Zero means no limit.
+	Rate int `json:"rate" mapstructure:"rate"`
+	// Concurrency is a maximum number of messages being sent at once. Zero means no limit.
+	Concurrency int `json:"concurrency" mapstructure:"concurrency"`
+}
+
+type AuthConfig struct {
+	// CacheTTL is a time for which API key validation results are cached.
+	CacheTTL time.Duration `json:"cache_ttl" mapstructure:"cache_ttl"`
+	// JWT accepts bearer tokens besides API keys when JWT.JWKS is set.
+	JWT JWTConfig `json:"jwt" mapstructure:"jwt"`
+}
+
+// JWTConfig configures authentication with OAuth2 access tokens.
+type JWTConfig struct {
+	// JWKS is a path or http(s) URL of JSON Web Key Set used to verify tokens.
+	JWKS string `json:"jwks" mapstructure:"jwks"`
+	// RefreshPeriod is a time after which JWKS is loaded again.
+	RefreshPeriod time.Duration `json:"refresh_period" mapstructure:"refresh_period"`
+	// Issuer and Audience are required values of iss and aud claims.
+	Issuer   string `json:"issuer" mapstructure:"issuer"`
+	Audience string `json:"audience" mapstructure:"audience"`
+	// Leeway is an allowed clock skew when exp and nbf claims are checked.
+	Leeway time.Duration `json:"leeway" mapstructure:"leeway"`
+	// TenantClaim is a name of required claim with tenant ID.
+	TenantClaim string `json:"tenant_claim" mapstructure:"tenant_claim"`
+	// ScopeClaim is a name of claim with space separated string or array of scopes.
+	ScopeClaim string `json:"scope_claim" mapstructure:"scope_claim"`
+	// Scopes maps values of ScopeClaim to scopes. Values which are names of scopes are mapped to themselves.
+	Scopes []ScopeMappingConfig `json:"scopes" mapstructure:"scopes"`
+}
+
+// ScopeMappingConfig maps value of scope claim to a scope.
+type ScopeMappingConfig struct {
+	Claim string `json:"claim" mapstructure:"claim"`
+	Scope string `json:"scope" mapstructure:"scope"`
+}
+
 // ConnectionString returns database connection string.
 func (c DBConfig) ConnectionString() string {
 	sslMode := "disable"
@@ -53,17 +292,95 @@ const (
 	// defaultConnMaxLifetimeSecs is the default maximum amount of time a connection may be reused.
 	defaultConnMaxLifetimeSecs = 30
 	// defaultMaxOpenConns is the default maximum number of open connections to the database.
-	defaultMaxOpenConns = 3
+	defaultMaxOpenConns = 20
 	// defaultMaxIdleConns is the default maximum number of connections in the idle connection pool.
-	defaultMaxIdleConns = 1
+	defaultMaxIdleConns = 5
+	// defaultRateLimitLeaseTTL is the default time after which abandoned concurrency slot is released.
+	defaultRateLimitLeaseTTL = time.Minute
+	// defaultAuthCacheTTL is the default time for which API key validation results are cached.
+	defaultAuthCacheTTL = 30 * time.Second
+	// defaultAdminPort is the default port of admin server.
+	defaultAdminPort = 9090
+	// defaultGRPCPort is the default port of gRPC server.
+	defaultGRPCPort = 9000
+	// defaultLogFormat is the default format of log entries.
+	defaultLogFormat = "json"
+	// defaultLogLevel is the default minimal level of logged entries.
+	defaultLogLevel = "info"
+	// defaultHealthCheckTimeout is the default time limit of readiness checks.
+	defaultHealthCheckTimeout = 2 * time.Second
+	// defaultHealthShutdownDelay is the default time for which unready service keeps serving on shutdown.
+	defaultHealthShutdownDelay = 5 * time.Second
+	// defaultTracingServiceName is the default service.name of exported spans.
+	defaultTracingServiceName = "vodeno"
+	// defaultTracingSampleRatio is the default fraction of recorded traces.
+	defaultTracingSampleRatio = 1.0
+	// defaultEventsKeepAlive is the default period of comments sent to idle event streams.
+	defaultEventsKeepAlive = 15 * time.Second
+	// defaultWebhookTickPeriod is the default period of checking for pending webhook deliveries.
+	defaultWebhookTickPeriod = 5 * time.Second
+	// defaultWebhookTimeout is the default time limit of a webhook delivery attempt.
+	defaultWebhookTimeout = 10 * time.Second
+	// defaultWebhookMaxAttempts is the default number of attempts after which webhook delivery fails.
+	defaultWebhookMaxAttempts = 8
+	// defaultWebhookBackoff is the default delay after the first failed webhook delivery attempt.
+	defaultWebhookBackoff = 30 * time.Second
+	// defaultWebhookBatchSize is the default number of webhook deliveries claimed at once.
+	defaultWebhookBatchSize = 20
+	// defaultRequestLimitStore is the default store of API request rate limits.
+	defaultRequestLimitStore = "memory"
+	// defaultHTTPReadHeaderTimeout is the default time limit of reading request headers.
+	defaultHTTPReadHeaderTimeout = 10 * time.Second
+	// defaultHTTPReadTimeout is the default time limit of reading a whole request.
+	defaultHTTPReadTimeout = 2 * time.Minute
+	// defaultHTTPIdleTimeout is the default time for which idle keep-alive connections are kept open.
+	defaultHTTPIdleTimeout = 2 * time.Minute
+	// defaultHTTPRequestTimeout is the default deadline of requests.
+	defaultHTTPRequestTimeout = 30 * time.Second
+	// defaultCORSMaxAge is the default time for which browsers cache preflight responses.
+	defaultCORSMaxAge = 10 * time.Minute
+	// defaultJWKSRefreshPeriod is the default time after which JWKS is loaded again.
+	defaultJWKSRefreshPeriod = time.Hour
+	// defaultJWTLeeway is the default allowed clock skew of token time claims.
+	defaultJWTLeeway = 30 * time.Second
+	// defaultJWTScopeClaim is the default name of claim with scopes.
+	defaultJWTScopeClaim = "scope"
+	// defaultJWTTenantClaim is the default name of claim with tenant ID.
+	defaultJWTTenantClaim = "tenant"
 )
 
 // Load loads configuration from the specified file.
 func Load() (*Config, error) {
 	viper := viper.New()
+	viper.SetDefault("admin_port", defaultAdminPort)
+	viper.SetDefault("grpc_port", defaultGRPCPort)
 	viper.SetDefault("db.conn_max_lifetime_secs", defaultConnMaxLifetimeSecs)
 	viper.SetDefault("db.max_open_conns", defaultMaxOpenConns)
 	viper.SetDefault("db.max_idle_conns", defaultMaxIdleConns)
+	viper.SetDefault("mail.rate_limit.lease_ttl", defaultRateLimitLeaseTTL)
+	viper.SetDefault("auth.cache_ttl", defaultAuthCacheTTL)
+	viper.SetDefault("log.format", defaultLogFormat)
+	viper.SetDefault("log.level", defaultLogLevel)
+	viper.SetDefault("health.check_timeout", defaultHealthCheckTimeout)
+	viper.SetDefault("health.shutdown_delay", defaultHealthShutdownDelay)
+	viper.SetDefault("tracing.service_name", defaultTracingServiceName)
+	viper.SetDefault("tracing.sample_ratio", defaultTracingSampleRatio)
+	viper.SetDefault("events.keep_alive", defaultEventsKeepAlive)
+	viper.SetDefault("webhook.tick_period", defaultWebhookTickPeriod)
+	viper.SetDefault("webhook.timeout", defaultWebhookTimeout)
+	viper.SetDefault("webhook.max_attempts", defaultWebhookMaxAttempts)
+	viper.SetDefault("webhook.backoff", defaultWebhookBackoff)
+	viper.SetDefault("webhook.batch_size", defaultWebhookBatchSize)
+	viper.SetDefault("rate_limit.store", defaultRequestLimitStore)
+	viper.SetDefault("http.read_header_timeout", defaultHTTPReadHeaderTimeout)
+	viper.SetDefault("http.read_timeout", defaultHTTPReadTimeout)
+	viper.SetDefault("http.idle_timeout", defaultHTTPIdleTimeout)
+	viper.SetDefault("http.request_timeout", defaultHTTPRequestTimeout)
+	viper.SetDefault("http.cors.max_age", defaultCORSMaxAge)
+	viper.SetDefault("auth.jwt.refresh_period", defaultJWKSRefreshPeriod)
+	viper.SetDefault("auth.jwt.leeway", defaultJWTLeeway)
+	viper.SetDefault("auth.jwt.scope_claim", defaultJWTScopeClaim)
+	viper.SetDefault("auth.jwt.tenant_claim", defaultJWTTenantClaim)
 
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
@@ -73,7 +390,7 @@ func Load() (*Config, error) {
 	mode := viper.GetString("mode")
 	viper.SetConfigName(mode)
 
-	viper.AddConfigPath("./config") // for go run cmd/main.go
+	viper.AddConfigPath("./config") // for go run ./cmd
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
@@ -84,6 +401,9 @@ func Load() (*Config, error) {
 	if err := viper.Unmarshal(&config); err != nil {
 		return nil, err
 	}
+	if err := config.HTTP.CORS.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &config, nil
 }
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"database/sql"
 	"errors"
 	"os"
 	"os/signal"
@@ -8,9 +9,19 @@ import (
 	"time"
 	"vodeno/pkg/config"
 
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
 )
 
+// tracedDriverName is a name of postgres driver which records span of every SQL statement.
+const tracedDriverName = "postgres-traced"
+
+func init() {
+	sql.Register(tracedDriverName, otelsql.WrapDriver(&pq.Driver{}, semconv.DBSystemPostgreSQL.Value.AsString()))
+}
+
 var (
 	// ErrIsReadyDBTimeout is returned when connection to the database couldn't be established in specified period of time.
 	ErrIsReadyDBTimeout = errors.New("DB ready check timeout")
@@ -53,12 +64,14 @@ func OpenDBWithTimeout(cfg config.DBConfig, waitForDBSecs int) (*sqlx.DB, error)
 	}
 }
 
-// OpenDB opens postgres database.
+// OpenDB opens postgres database. Statements executed with context of a traced
+// operation are recorded as its child spans.
 func OpenDB(cfg config.DBConfig) (*sqlx.DB, error) {
-	db, err := sqlx.Open("postgres", cfg.ConnectionString())
+	sqlDB, err := sql.Open(tracedDriverName, cfg.ConnectionString())
 	if err != nil {
 		return nil, err
 	}
+	db := sqlx.NewDb(sqlDB, "postgres")
 
 	db.SetMaxOpenConns(cfg.MaxOpenConns)
 	db.SetMaxIdleConns(cfg.MaxIdleConns)
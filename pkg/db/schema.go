@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SchemaVersion is a version of the latest migration in db directory required by the service.
+const SchemaVersion = 20211215100000
+
+// CheckSchemaVersion returns error if migration SchemaVersion isn't applied to the database.
+func CheckSchemaVersion(ctx context.Context, db *sqlx.DB) error {
+	var version sql.NullInt64
+	if err := db.GetContext(ctx, &version, "SELECT max(version) FROM schema_migration"); err != nil {
+		return err
+	}
+	if !version.Valid || version.Int64 < SchemaVersion {
+		return fmt.Errorf("schema version %d is older than required %d", version.Int64, SchemaVersion)
+	}
+	return nil
+}
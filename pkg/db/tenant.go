@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TenantRole is a database role restricted by row-level security to rows of app.tenant_id.
+const TenantRole = "vodeno_tenant"
+
+// InTenant runs fn in a transaction as TenantRole, restricted to rows of given tenant.
+// The transaction is committed when fn succeeds and rolled back otherwise.
+func InTenant(ctx context.Context, db *sqlx.DB, tenantID string, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "SET LOCAL ROLE "+TenantRole); err != nil {
+		_ = tx.Rollback() // Error of the statement is more useful than error of the rollback.
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "SELECT set_config('app.tenant_id', $1, true)", tenantID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
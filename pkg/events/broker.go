@@ -0,0 +1,86 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// subscriptionBuffer is the number of Events buffered for a subscriber.
+// Events are dropped for subscribers which don't keep up.
+const subscriptionBuffer = 64
+
+// Broker fans out Events received from Postgres to subscribers of this replica.
+type Broker struct {
+	log *logrus.Entry
+
+	mu          sync.Mutex
+	subscribers map[chan Event]Filter
+}
+
+// NewBroker returns new instance of Broker.
+func NewBroker(logger *logrus.Logger) *Broker {
+	return &Broker{
+		log:         logger.WithField("place", "events_broker"),
+		subscribers: make(map[chan Event]Filter),
+	}
+}
+
+// Subscribe returns channel of Events selected by filter and function which cancels subscription.
+func (b *Broker) Subscribe(filter Filter) (<-chan Event, func()) {
+	ch := make(chan Event, subscriptionBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = filter
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+	}
+}
+
+// Dispatch delivers Event to matching subscribers.
+func (b *Broker) Dispatch(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch, filter := range b.subscribers {
+		if !filter.Match(e) {
+			continue
+		}
+		select {
+		case ch <- e:
+		default:
+			b.log.WithField("type", e.Type).Warn("subscriber is too slow, event dropped")
+		}
+	}
+}
+
+// Run dispatches Events of notifications until ctx is done or notifications are closed,
+// e.g. pq.Listener's Notify channel.
+func (b *Broker) Run(ctx context.Context, notifications <-chan *pq.Notification) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n, ok := <-notifications:
+			if !ok {
+				return
+			}
+			if n == nil { // connection was re-established, notifications could be missed.
+				b.log.Warn("listener reconnected")
+				continue
+			}
+			var e Event
+			if err := json.Unmarshal([]byte(n.Extra), &e); err != nil {
+				b.log.WithError(err).Error("failed to decode event")
+				continue
+			}
+			b.Dispatch(e)
+		}
+	}
+}
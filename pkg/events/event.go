@@ -0,0 +1,57 @@
+// Package events publishes lifecycle events of entries and mailings with Postgres NOTIFY
+// and streams them to API clients as server-sent events.
+package events
+
+import (
+	"context"
+	"time"
+)
+
+// Type is a type of lifecycle Event.
+type Type string
+
+const (
+	EntryCreated  Type = "entry.created"  // entry was added.
+	EntryDeleted  Type = "entry.deleted"  // entry was deleted through the API.
+	EntryExpired  Type = "entry.expired"  // entry was deleted by Watcher after its TTL.
+	SendStarted   Type = "send.started"   // sending of a mailing started, Entries is the number of its entries.
+	MessageSent   Type = "message.sent"   // message to entry was sent.
+	SendCompleted Type = "send.completed" // sending of a mailing finished, Sent, Suppressed and Failed count entries.
+
+	MessageBounced    Type = "message.bounced"    // message to Email bounced, Detail describes why.
+	MessageComplained Type = "message.complained" // recipient Email complained about message.
+)
+
+// Event is a lifecycle event of entries and mailings.
+type Event struct {
+	Type      Type   `json:"type"`
+	TenantID  string `json:"tenant_id"`
+	MailingID int    `json:"mailing_id"`
+	EntryID   int    `json:"entry_id,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+	// Entries, Sent, Suppressed and Failed count entries of send events.
+	Entries    int       `json:"entries,omitempty"`
+	Sent       int       `json:"sent,omitempty"`
+	Suppressed int       `json:"suppressed,omitempty"`
+	Failed     int       `json:"failed,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// Publisher publishes Events to subscribers of every replica.
+type Publisher interface {
+	// Publish publishes events, their TenantID must be set.
+	Publish(ctx context.Context, events ...Event) error
+}
+
+// Filter selects Events delivered to a subscriber.
+type Filter struct {
+	TenantID string
+	// MailingID selects Events of a mailing, Events of all mailings are selected if it's zero.
+	MailingID int
+}
+
+// Match returns true if Event is selected by Filter.
+func (f Filter) Match(e Event) bool {
+	return e.TenantID == f.TenantID && (f.MailingID == 0 || e.MailingID == f.MailingID)
+}
@@ -0,0 +1,112 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/middleware"
+	"vodeno/pkg/problem"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// Handler is a http handler streaming Events as server-sent events.
+type Handler struct {
+	broker *Broker
+	log    *logrus.Logger
+	// keepAlive is a period of comments sent to keep idle connections open.
+	keepAlive time.Duration
+	// done is closed on Shutdown to end open streams.
+	done     chan struct{}
+	shutdown sync.Once
+}
+
+// NewHandler returns new instance of Handler.
+func NewHandler(log *logrus.Logger, broker *Broker, keepAlive time.Duration) *Handler {
+	return &Handler{
+		broker:    broker,
+		log:       log,
+		keepAlive: keepAlive,
+		done:      make(chan struct{}),
+	}
+}
+
+// Shutdown ends open streams, so http.Server.Shutdown doesn't wait for them. Clients reconnect to other instances.
+func (h *Handler) Shutdown() {
+	h.shutdown.Do(func() { close(h.done) })
+}
+
+// AddRoutes adds events route to router. It requires clients:read scope.
+func (h *Handler) AddRoutes(router chi.Router) {
+	router.With(middleware.RequireScope(auth.ScopeClientsRead)).Get("/events", h.stream)
+}
+
+// stream streams Events of the caller's tenant until the client disconnects.
+// It accepts mailing_id filter. Every Event is sent with its type as SSE event name and JSON data.
+func (h *Handler) stream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "streamEvents")
+
+	tenantID, ok := auth.TenantFromContext(ctx)
+	if !ok {
+		logger.Error("missing tenant")
+		problem.Write(w, r, problem.New(http.StatusForbidden, problem.CodeForbidden, auth.ErrNoTenant.Error()))
+		return
+	}
+	filter := Filter{TenantID: tenantID}
+	if mailingID := r.URL.Query().Get("mailing_id"); mailingID != "" {
+		id, err := strconv.Atoi(mailingID)
+		if err != nil {
+			logger.WithError(err).Error("invalid mailing_id")
+			problem.Write(w, r, problem.InvalidParameter("mailing_id", err))
+			return
+		}
+		filter.MailingID = id
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		logger.Error("streaming is not supported")
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	events, unsubscribe := h.broker.Subscribe(filter)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(h.keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.done:
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case e := <-events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				logger.WithError(err).Error("failed to encode event")
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,131 @@
+package events_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/events"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter_Match(t *testing.T) {
+	event := events.Event{Type: events.EntryCreated, TenantID: "t1", MailingID: 5}
+
+	for _, tt := range []struct {
+		name   string
+		filter events.Filter
+		wanted bool
+	}{
+		{name: "MatchesTenant", filter: events.Filter{TenantID: "t1"}, wanted: true},
+		{name: "MatchesMailing", filter: events.Filter{TenantID: "t1", MailingID: 5}, wanted: true},
+		{name: "SkipsOtherTenant", filter: events.Filter{TenantID: "t2"}},
+		{name: "SkipsOtherMailing", filter: events.Filter{TenantID: "t1", MailingID: 6}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.wanted, tt.filter.Match(event))
+		})
+	}
+}
+
+func TestBroker_Run(t *testing.T) {
+	log := logrus.New()
+	log.Out = io.Discard
+
+	broker := events.NewBroker(log)
+	received, unsubscribe := broker.Subscribe(events.Filter{TenantID: "t1", MailingID: 5})
+	defer unsubscribe()
+
+	notifications := make(chan *pq.Notification, 4)
+	for _, e := range []events.Event{
+		{Type: events.EntryCreated, TenantID: "t2", MailingID: 5},
+		{Type: events.EntryCreated, TenantID: "t1", MailingID: 6},
+		{Type: events.SendStarted, TenantID: "t1", MailingID: 5, Entries: 3},
+	} {
+		payload, err := json.Marshal(e)
+		require.NoError(t, err)
+		notifications <- &pq.Notification{Channel: events.Channel, Extra: string(payload)}
+	}
+	notifications <- &pq.Notification{Channel: events.Channel, Extra: "not json"}
+	close(notifications)
+
+	broker.Run(context.Background(), notifications)
+
+	require.Len(t, received, 1)
+	e := <-received
+	require.Equal(t, events.SendStarted, e.Type)
+	require.Equal(t, 3, e.Entries)
+}
+
+// newTestServer returns server of Handler. Requests are made by a Principal of tenant t1.
+func newTestServer(t *testing.T, handler *events.Handler) *httptest.Server {
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := &auth.Principal{ID: "apikey:1", TenantID: "t1", Scopes: []auth.Scope{auth.ScopeClientsRead}}
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+		})
+	})
+	handler.AddRoutes(router)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHandler_stream(t *testing.T) {
+	log := logrus.New()
+	log.Out = io.Discard
+
+	broker := events.NewBroker(log)
+	handler := events.NewHandler(log, broker, time.Hour)
+	server := newTestServer(t, handler)
+
+	resp, err := http.Get(server.URL + "/events?mailing_id=5")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// headers are flushed after subscribing, so dispatched events are received.
+	broker.Dispatch(events.Event{Type: events.EntryCreated, TenantID: "t1", MailingID: 6, EntryID: 1})
+	broker.Dispatch(events.Event{Type: events.EntryCreated, TenantID: "t1", MailingID: 5, EntryID: 2})
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "event: entry.created\n", line)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(line, "data: "))
+
+	var e events.Event
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e))
+	require.Equal(t, 2, e.EntryID)
+
+	handler.Shutdown()
+	_, err = io.ReadAll(reader)
+	require.NoError(t, err)
+}
+
+func TestHandler_streamInvalidMailingID(t *testing.T) {
+	log := logrus.New()
+	log.Out = io.Discard
+
+	server := newTestServer(t, events.NewHandler(log, events.NewBroker(log), time.Hour))
+
+	resp, err := http.Get(server.URL + "/events?mailing_id=x")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,66 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+	"vodeno/pkg/config"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// Channel is a Postgres notification channel of Events, payloads are JSON encoded Events.
+const Channel = "vodeno_events"
+
+const (
+	minReconnectInterval = time.Second // listener's first reconnection delay.
+	maxReconnectInterval = time.Minute // listener's maximum reconnection delay.
+)
+
+// publisher is postgresql implementation of Publisher.
+type publisher struct {
+	db *sqlx.DB
+}
+
+// NewPublisher returns Publisher which notifies Channel.
+func NewPublisher(db *sqlx.DB) Publisher {
+	return publisher{db: db}
+}
+
+// Publish sends all events in one statement. Notifications of a transaction are delivered when it commits,
+// so events are published after changes they describe are visible.
+func (p publisher) Publish(ctx context.Context, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	payloads := make([]string, 0, len(events))
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		payloads = append(payloads, string(payload))
+	}
+	_, err := p.db.ExecContext(ctx, "SELECT pg_notify($1, payload) FROM unnest($2::text[]) AS payload",
+		Channel, pq.StringArray(payloads))
+	return err
+}
+
+// NewListener returns pq.Listener of Channel. It reconnects when connection is lost,
+// Events published in the meantime are missed.
+func NewListener(log *logrus.Logger, cfg config.DBConfig) (*pq.Listener, error) {
+	logger := log.WithField("place", "events_listener")
+	listener := pq.NewListener(cfg.ConnectionString(), minReconnectInterval, maxReconnectInterval,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				logger.WithError(err).WithField("event", event).Warn("listener connection failed")
+			}
+		})
+	if err := listener.Listen(Channel); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
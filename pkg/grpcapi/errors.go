@@ -0,0 +1,79 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"vodeno/pkg/client"
+	"vodeno/pkg/problem"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusFor maps error returned by Service to gRPC status, like client.Handler maps it to Problem.
+// Unknown errors are internal, their details are only logged.
+func statusFor(err error) error {
+	switch {
+	case errors.Is(err, client.ErrDuplicate):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, client.ErrSuppressed):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, client.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, client.ErrConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, client.ErrValidation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+// httpStatus returns HTTP status equivalent to gRPC status code, it's the status of audit Records of calls.
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// validationStatus returns InvalidArgument status with BadRequest details listing invalid fields.
+func validationStatus(err error) error {
+	p := problem.Validation(err)
+	st := status.New(codes.InvalidArgument, p.Detail)
+	details := &errdetails.BadRequest{}
+	for _, f := range p.Errors {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       f.Field,
+			Description: f.Message,
+		})
+	}
+	if withDetails, err := st.WithDetails(details); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
@@ -0,0 +1,420 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+	"vodeno/pkg/audit"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/grpcapi/clientpb"
+	"vodeno/pkg/metrics"
+	"vodeno/pkg/middleware"
+	"vodeno/pkg/ratelimit"
+	"vodeno/pkg/requestid"
+	"vodeno/pkg/tracing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	xTokenMetadata        = "x-token"       // x-token metadata with API key.
+	authorizationMetadata = "authorization" // authorization metadata with access token.
+	bearerScheme          = "bearer"        // authorization metadata scheme of OAuth2 access tokens.
+	retryAfterMetadata    = "retry-after"   // retry-after metadata with seconds until rate limited call is allowed.
+)
+
+const (
+	// rateLimitGroup is a rate limit group of calls, they share limits of client.Handler routes.
+	rateLimitGroup = "clients"
+	// authRateLimitGroup is a rate limit group of calls by client IP before authentication,
+	// shared with HTTP requests.
+	authRateLimitGroup = "auth"
+)
+
+// requestIDMetadata is a metadata key with request ID, metadata keys are lower-case.
+var requestIDMetadata = strings.ToLower(requestid.Header)
+
+// methodScopes are scopes required by methods, like scopes of client.Handler routes.
+// Methods which are not listed are denied.
+var methodScopes = map[string]auth.Scope{
+	fullMethod("AddClient"):    auth.ScopeClientsWrite,
+	fullMethod("Send"):         auth.ScopeMailingsSend,
+	fullMethod("DeleteClient"): auth.ScopeClientsWrite,
+	fullMethod("GetClient"):    auth.ScopeClientsRead,
+	fullMethod("ListClients"):  auth.ScopeClientsRead,
+}
+
+// auditedMethods are mutating methods recorded in audit trail, like mutating requests of client.Handler.
+var auditedMethods = map[string]bool{
+	fullMethod("AddClient"):    true,
+	fullMethod("Send"):         true,
+	fullMethod("DeleteClient"): true,
+}
+
+func fullMethod(name string) string {
+	return "/" + clientpb.ClientService_ServiceDesc.ServiceName + "/" + name
+}
+
+// UnaryLoggerInterceptor is an access log interceptor, gRPC counterpart of middleware.LoggerMiddleware.
+// It takes valid request ID from x-requestid metadata or generates one, stores it in context and sends it back in header.
+func UnaryLoggerInterceptor(log *logrus.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx = withRequestID(ctx)
+		resp, err := handler(ctx, req)
+		logCall(ctx, log, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamLoggerInterceptor is UnaryLoggerInterceptor of streaming calls.
+func StreamLoggerInterceptor(log *logrus.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := withRequestID(ss.Context())
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		logCall(ctx, log, info.FullMethod, start, err)
+		return err
+	}
+}
+
+// UnaryTracingInterceptor starts server span of every call, gRPC counterpart of tracing.Middleware.
+// It continues trace from traceparent metadata.
+func UnaryTracingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := startSpan(ctx, info.FullMethod)
+		resp, err := handler(ctx, req)
+		endSpan(span, err)
+		return resp, err
+	}
+}
+
+// StreamTracingInterceptor is UnaryTracingInterceptor of streaming calls.
+func StreamTracingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startSpan(ss.Context(), info.FullMethod)
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		endSpan(span, err)
+		return err
+	}
+}
+
+// UnaryMetricsInterceptor counts calls and observes their latency by method and status code,
+// gRPC counterpart of metrics.Middleware.
+func UnaryMetricsInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		metrics.ObserveGRPCCall(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return resp, err
+	}
+}
+
+// StreamMetricsInterceptor is UnaryMetricsInterceptor of streaming calls.
+func StreamMetricsInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		metrics.ObserveGRPCCall(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return err
+	}
+}
+
+// UnaryAuthInterceptor authenticates calls with access token in authorization metadata by tokens
+// or with API key in x-token metadata by apiKeys and checks scope required by the method.
+// When tokens is nil, API keys are accepted in authorization metadata too.
+// It returns UNAUTHENTICATED or PERMISSION_DENIED status, authenticated Principal is stored in context.
+func UnaryAuthInterceptor(log *logrus.Logger, apiKeys, tokens middleware.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, log, apiKeys, tokens, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor is UnaryAuthInterceptor of streaming calls.
+func StreamAuthInterceptor(log *logrus.Logger, apiKeys, tokens middleware.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), log, apiKeys, tokens, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// UnaryRateLimitInterceptor limits calls of every Principal, gRPC counterpart of ratelimit.Limiter's Middleware.
+// It must be used after authentication. Calls share limits of client.Handler routes with HTTP requests.
+// It returns RESOURCE_EXHAUSTED status and retry-after metadata when the limit is exceeded.
+func UnaryRateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := allow(ctx, limiter); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimitInterceptor is UnaryRateLimitInterceptor of streaming calls, a stream takes a single token.
+func StreamRateLimitInterceptor(limiter *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := allow(ss.Context(), limiter); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// UnaryPeerRateLimitInterceptor limits calls of every client IP before authentication, so credentials
+// can't be guessed at the cost of a database query each, as invalid ones aren't cached.
+// Calls share limits of the auth group with HTTP requests.
+func UnaryPeerRateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := allowPeer(ctx, limiter); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamPeerRateLimitInterceptor is UnaryPeerRateLimitInterceptor of streaming calls.
+func StreamPeerRateLimitInterceptor(limiter *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := allowPeer(ss.Context(), limiter); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// UnaryAuditInterceptor records calls of mutating methods in audit trail, gRPC counterpart of audit.Middleware.
+// It must be used after authentication, Principal from context is the actor.
+// Action of Records is GRPC and full method, e.g. GRPC /vodeno.client.v1.ClientService/Send.
+func UnaryAuditInterceptor(log *logrus.Logger, recorder audit.Recorder) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		if !auditedMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		audit.Call(ctx, log, recorder, "GRPC "+info.FullMethod, func(ctx context.Context) int {
+			resp, err = handler(ctx, req)
+			return httpStatus(status.Code(err))
+		})
+		return resp, err
+	}
+}
+
+// StreamAuditInterceptor is UnaryAuditInterceptor of streaming calls.
+func StreamAuditInterceptor(log *logrus.Logger, recorder audit.Recorder) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		if !auditedMethods[info.FullMethod] {
+			return handler(srv, ss)
+		}
+		audit.Call(ss.Context(), log, recorder, "GRPC "+info.FullMethod, func(ctx context.Context) int {
+			err = handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+			return httpStatus(status.Code(err))
+		})
+		return err
+	}
+}
+
+// UnaryRecoverInterceptor recovers from panics of handlers, gRPC counterpart of middleware.RecoverMiddleware.
+// It logs the panic with stack and request ID and returns INTERNAL status.
+func UnaryRecoverInterceptor(log *logrus.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = recovered(ctx, log, rec)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecoverInterceptor is UnaryRecoverInterceptor of streaming calls.
+func StreamRecoverInterceptor(log *logrus.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = recovered(ss.Context(), log, rec)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// recovered logs recovered panic and returns INTERNAL status.
+func recovered(ctx context.Context, log *logrus.Logger, rec interface{}) error {
+	log.WithContext(ctx).WithFields(logrus.Fields{
+		"panic": rec,
+		"stack": string(debug.Stack()),
+	}).Error("handler panicked")
+	return status.Error(codes.Internal, "")
+}
+
+// serverStream is grpc.ServerStream with replaced context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// withRequestID returns context with request ID of the call.
+func withRequestID(ctx context.Context) context.Context {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDMetadata); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	if !requestid.Valid(requestID) {
+		requestID = uuid.New().String()
+	}
+	// header is sent with the first response message, it fails only if it was sent already.
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, requestID))
+	return requestid.NewContext(ctx, requestID)
+}
+
+// startSpan starts server span of call of full method, continuing trace from the call's metadata.
+func startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = tracing.Extract(ctx, md)
+
+	attrs := []attribute.KeyValue{semconv.RPCSystemKey.String("grpc")}
+	if parts := strings.SplitN(strings.TrimPrefix(method, "/"), "/", 2); len(parts) == 2 {
+		attrs = append(attrs, semconv.RPCServiceKey.String(parts[0]), semconv.RPCMethodKey.String(parts[1]))
+	}
+	return tracing.Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endSpan records status code of finished call on span and ends it.
+func endSpan(span trace.Span, err error) {
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(status.Code(err))))
+	tracing.End(span, err)
+}
+
+// allow takes a token of Principal from context, it returns RESOURCE_EXHAUSTED status if there is none.
+func allow(ctx context.Context, limiter *ratelimit.Limiter) error {
+	principal, ok := auth.FromContext(ctx)
+	if !ok { // unauthenticated calls are rejected by auth interceptor.
+		return nil
+	}
+	return take(ctx, limiter, rateLimitGroup, principal.ID)
+}
+
+// allowPeer takes a token of client IP from context, it returns RESOURCE_EXHAUSTED status if there is none.
+// Keys are the same as those of ratelimit.Limiter's Middleware for unauthenticated requests.
+func allowPeer(ctx context.Context, limiter *ratelimit.Limiter) error {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	addr := p.Addr.String()
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return take(ctx, limiter, authRateLimitGroup, "ip:"+host)
+}
+
+// take takes a token of caller in group, it returns RESOURCE_EXHAUSTED status with retry-after metadata
+// when the limit is exceeded.
+func take(ctx context.Context, limiter *ratelimit.Limiter, group, caller string) error {
+	retryAfter, ok := limiter.Allow(ctx, group, caller)
+	if ok {
+		return nil
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(retryAfterMetadata, strconv.Itoa(retryAfter)))
+	return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %d seconds", retryAfter)
+}
+
+// logCall logs finished call.
+func logCall(ctx context.Context, log *logrus.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	requestID, _ := requestid.FromContext(ctx)
+	entry := log.WithFields(logrus.Fields{
+		"request_id":  requestID,
+		"method":      method,
+		"code":        code.String(),
+		"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+	})
+	switch code {
+	case codes.OK:
+		entry.Info("call completed")
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		entry.Error("call completed")
+	default:
+		entry.Warn("call completed")
+	}
+}
+
+// authenticate returns context with Principal authenticated by the call's credentials.
+func authenticate(
+	ctx context.Context, log *logrus.Logger, apiKeys, tokens middleware.Authenticator, method string,
+) (context.Context, error) {
+	key, token := callCredentials(ctx)
+	var authenticator middleware.Authenticator
+	switch {
+	case token != "" && tokens != nil:
+		authenticator, key = tokens, token
+	case key != "":
+		authenticator = apiKeys
+	case token != "": // API key in authorization metadata.
+		authenticator, key = apiKeys, token
+	default:
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid credentials")
+	}
+	principal, err := authenticator.Authenticate(ctx, key)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Warn("authentication failed")
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid credentials")
+	}
+
+	scope, ok := methodScopes[method]
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "method is not allowed")
+	}
+	if !principal.HasScope(scope) {
+		return nil, status.Error(codes.PermissionDenied, "missing required scope: "+string(scope))
+	}
+	return auth.NewContext(ctx, principal), nil
+}
+
+// callCredentials returns API key from x-token metadata and token from authorization metadata of the call,
+// they are empty if there are none.
+func callCredentials(ctx context.Context) (key, token string) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", ""
+	}
+	if values := md.Get(xTokenMetadata); len(values) > 0 {
+		key = values[0]
+	}
+	for _, value := range md.Get(authorizationMetadata) {
+		parts := strings.SplitN(value, " ", 2)
+		if len(parts) == 2 && strings.EqualFold(parts[0], bearerScheme) {
+			token = strings.TrimSpace(parts[1])
+			break
+		}
+	}
+	return key, token
+}
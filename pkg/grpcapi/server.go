@@ -0,0 +1,203 @@
+// Package grpcapi serves client.Service over gRPC for internal services, see proto/client.proto.
+package grpcapi
+
+import (
+	"context"
+	"vodeno/pkg/audit"
+	"vodeno/pkg/client"
+	"vodeno/pkg/grpcapi/clientpb"
+	"vodeno/pkg/middleware"
+	"vodeno/pkg/problem"
+	"vodeno/pkg/ratelimit"
+
+	"github.com/go-playground/validator"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+//go:generate protoc -I ../../proto --go_out=clientpb --go_opt=paths=source_relative --go-grpc_out=clientpb --go-grpc_opt=paths=source_relative client.proto
+
+// listPageSize is the number of entries fetched from Service at once by ListClients.
+const listPageSize = 100
+
+// Server implements ClientService with client.Service, the same one used by client.Handler.
+type Server struct {
+	clientpb.UnimplementedClientServiceServer
+
+	service   client.Service
+	validator *validator.Validate
+	log       *logrus.Logger
+}
+
+// NewServer returns new instance of Server.
+func NewServer(log *logrus.Logger, svc client.Service) *Server {
+	return &Server{
+		service:   svc,
+		validator: problem.NewValidator(),
+		log:       log,
+	}
+}
+
+// NewGRPCServer returns grpc.Server serving ClientService with interceptors of the HTTP API middlewares:
+// tracing, logger, metrics, client IP rate limit, authentication, rate limit and audit. Calls are
+// authenticated with access tokens by tokens, it may be nil, or API keys by apiKeys, see UnaryAuthInterceptor.
+func NewGRPCServer(
+	log *logrus.Logger,
+	apiKeys, tokens middleware.Authenticator,
+	recorder audit.Recorder,
+	limiter *ratelimit.Limiter,
+	srv *Server,
+) *grpc.Server {
+	// unauthenticated calls are limited by client IP only and not audited, like HTTP requests.
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			UnaryTracingInterceptor(),
+			UnaryLoggerInterceptor(log),
+			UnaryMetricsInterceptor(),
+			UnaryRecoverInterceptor(log),
+			UnaryPeerRateLimitInterceptor(limiter),
+			UnaryAuthInterceptor(log, apiKeys, tokens),
+			UnaryRateLimitInterceptor(limiter),
+			UnaryAuditInterceptor(log, recorder),
+		),
+		grpc.ChainStreamInterceptor(
+			StreamTracingInterceptor(),
+			StreamLoggerInterceptor(log),
+			StreamMetricsInterceptor(),
+			StreamRecoverInterceptor(log),
+			StreamPeerRateLimitInterceptor(limiter),
+			StreamAuthInterceptor(log, apiKeys, tokens),
+			StreamRateLimitInterceptor(limiter),
+			StreamAuditInterceptor(log, recorder),
+		),
+	)
+	clientpb.RegisterClientServiceServer(s, srv)
+	return s
+}
+
+// AddClient validates entry and calls Service for creation.
+func (s *Server) AddClient(ctx context.Context, req *clientpb.AddClientRequest) (*emptypb.Empty, error) {
+	logger := s.log.WithContext(ctx).WithField("handler", "grpcAddClient")
+
+	entry := entryFromProto(req.GetEntry())
+	if err := s.validator.Struct(entry); err != nil {
+		logger.WithError(err).Error("request is not valid")
+		return nil, validationStatus(err)
+	}
+
+	audit.AddTarget(ctx, "mailing_id", entry.MailingID)
+	if err := s.service.Add(ctx, entry); err != nil {
+		logger.WithError(err).Error("failed to add client")
+		return nil, statusFor(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// Send calls Service's Send method.
+func (s *Server) Send(ctx context.Context, req *clientpb.SendRequest) (*emptypb.Empty, error) {
+	logger := s.log.WithContext(ctx).WithField("handler", "grpcSend").WithField("mailing_id", req.GetMailingId())
+
+	if req.GetMailingId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "mailing_id is required")
+	}
+
+	audit.AddTarget(ctx, "mailing_id", req.GetMailingId())
+	if err := s.service.Send(ctx, int(req.GetMailingId())); err != nil {
+		logger.WithError(err).Error("failed to send emails")
+		return nil, statusFor(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteClient calls Service's Delete method.
+func (s *Server) DeleteClient(ctx context.Context, req *clientpb.DeleteClientRequest) (*emptypb.Empty, error) {
+	logger := s.log.WithContext(ctx).WithField("handler", "grpcDeleteClient")
+
+	audit.AddTarget(ctx, "id", req.GetId())
+	if err := s.service.Delete(ctx, int(req.GetId())); err != nil {
+		logger.WithError(err).Error("failed to delete client")
+		return nil, statusFor(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// GetClient calls Service's Get method.
+func (s *Server) GetClient(ctx context.Context, req *clientpb.GetClientRequest) (*clientpb.Entry, error) {
+	logger := s.log.WithContext(ctx).WithField("handler", "grpcGetClient")
+
+	entry, err := s.service.Get(ctx, int(req.GetId()))
+	if err != nil {
+		logger.WithError(err).Error("failed to get client")
+		return nil, statusFor(err)
+	}
+	return entryToProto(*entry), nil
+}
+
+// ListClients streams entries fetched from Service page by page, until req.Limit entries are sent
+// or there are no more.
+func (s *Server) ListClients(req *clientpb.ListClientsRequest, stream clientpb.ClientService_ListClientsServer) error {
+	ctx := stream.Context()
+
+	logger := s.log.WithContext(ctx).WithField("handler", "grpcListClients")
+
+	if req.GetLimit() < 0 {
+		return status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+	cursor := client.Cursor{Limit: listPageSize}
+	if req.GetAfterId() > 0 {
+		afterID := int(req.GetAfterId())
+		cursor.AfterID = &afterID
+	}
+
+	var sent int64
+	for {
+		if left := req.GetLimit() - sent; req.GetLimit() > 0 && left < int64(cursor.Limit) {
+			cursor.Limit = int(left)
+		}
+		entries, err := s.service.List(ctx, cursor)
+		if err != nil {
+			logger.WithError(err).Error("failed to get clients")
+			return statusFor(err)
+		}
+		for _, e := range entries {
+			if err := stream.Send(entryToProto(e)); err != nil {
+				logger.WithError(err).Error("failed to send entry")
+				return err
+			}
+		}
+		sent += int64(len(entries))
+		if len(entries) < cursor.Limit || sent == req.GetLimit() {
+			return nil
+		}
+		cursor.AfterID = &entries[len(entries)-1].ID
+	}
+}
+
+func entryFromProto(e *clientpb.Entry) client.Entry {
+	entry := client.Entry{
+		ID:        int(e.GetId()),
+		Email:     e.GetEmail(),
+		Title:     e.GetTitle(),
+		Content:   e.GetContent(),
+		MailingID: int(e.GetMailingId()),
+	}
+	if e.GetInsertTime() != nil {
+		entry.InsertTime = e.GetInsertTime().AsTime()
+	}
+	return entry
+}
+
+func entryToProto(e client.Entry) *clientpb.Entry {
+	return &clientpb.Entry{
+		Id:         int64(e.ID),
+		Email:      e.Email,
+		Title:      e.Title,
+		Content:    e.Content,
+		MailingId:  int64(e.MailingID),
+		InsertTime: timestamppb.New(e.InsertTime),
+	}
+}
@@ -0,0 +1,382 @@
+package grpcapi_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"vodeno/pkg/audit"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/client"
+	"vodeno/pkg/config"
+	"vodeno/pkg/grpcapi"
+	"vodeno/pkg/grpcapi/clientpb"
+	"vodeno/pkg/mocks"
+	"vodeno/pkg/ratelimit"
+	"vodeno/pkg/requestid"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// authenticator accepts keys named after scope they grant, following prefix.
+type authenticator struct {
+	prefix string
+}
+
+func (a authenticator) Authenticate(_ context.Context, key string) (*auth.Principal, error) {
+	if !strings.HasPrefix(key, a.prefix) {
+		return nil, errors.New("invalid key")
+	}
+	scope, err := auth.ParseScope(strings.TrimPrefix(key, a.prefix))
+	if err != nil {
+		return nil, err
+	}
+	return &auth.Principal{ID: "apikey:1", Name: "test", Scopes: []auth.Scope{scope}}, nil
+}
+
+// recorderFunc is an adapter to use function as audit.Recorder.
+type recorderFunc func(ctx context.Context, r audit.Record) error
+
+func (f recorderFunc) Record(ctx context.Context, r audit.Record) error {
+	return f(ctx, r)
+}
+
+// newTestClient returns ClientService client of Server backed by service mock, calls are not limited.
+func newTestClient(t *testing.T, mock *mocks.MockService) clientpb.ClientServiceClient {
+	nop := recorderFunc(func(context.Context, audit.Record) error { return nil })
+	return newTestClientWith(t, mock, nop, config.RequestLimitConfig{})
+}
+
+// newTestClientWith returns ClientService client of Server backed by service mock,
+// with given audit recorder and rate limits.
+func newTestClientWith(
+	t *testing.T, mock *mocks.MockService, recorder audit.Recorder, limits config.RequestLimitConfig,
+) clientpb.ClientServiceClient {
+	log := logrus.New()
+	log.Out = io.Discard
+
+	lis := bufconn.Listen(1 << 20)
+	limiter := ratelimit.NewLimiter(log, ratelimit.NewMemoryStore(), limits)
+	srv := grpcapi.NewGRPCServer(log, authenticator{}, authenticator{prefix: "jwt:"}, recorder, limiter, grpcapi.NewServer(log, mock))
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithInsecure(),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return clientpb.NewClientServiceClient(conn)
+}
+
+// withKey returns context of calls authenticated with given key.
+func withKey(key auth.Scope) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-token", string(key))
+}
+
+func TestServer_authentication(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		ctx        context.Context
+		prep       func(mock *mocks.MockService)
+		wantedCode codes.Code
+	}{
+		{
+			name: "AcceptsAPIKey",
+			ctx:  withKey(auth.ScopeClientsRead),
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Get(gomock.Any(), 1).Return(&client.Entry{ID: 1}, nil)
+			},
+			wantedCode: codes.OK,
+		},
+		{
+			name: "AcceptsBearerToken",
+			ctx:  metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer jwt:admin"),
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Get(gomock.Any(), 1).Return(&client.Entry{ID: 1}, nil)
+			},
+			wantedCode: codes.OK,
+		},
+		{
+			name:       "ReturnsUnauthenticatedOnAPIKeyAsBearerToken",
+			ctx:        metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer admin"),
+			wantedCode: codes.Unauthenticated,
+		},
+		{
+			name:       "ReturnsUnauthenticatedWithoutKey",
+			ctx:        context.Background(),
+			wantedCode: codes.Unauthenticated,
+		},
+		{
+			name:       "ReturnsUnauthenticatedOnInvalidKey",
+			ctx:        withKey("invalid"),
+			wantedCode: codes.Unauthenticated,
+		},
+		{
+			name:       "ReturnsPermissionDeniedWithoutScope",
+			ctx:        withKey(auth.ScopeClientsWrite),
+			wantedCode: codes.PermissionDenied,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mocks.NewMockService(gomock.NewController(t))
+			if tt.prep != nil {
+				tt.prep(mock)
+			}
+
+			_, err := newTestClient(t, mock).GetClient(tt.ctx, &clientpb.GetClientRequest{Id: 1})
+			require.Equal(t, tt.wantedCode, status.Code(err), err)
+		})
+	}
+}
+
+func TestServer_requestID(t *testing.T) {
+	mock := mocks.NewMockService(gomock.NewController(t))
+	mock.EXPECT().Delete(gomock.Any(), 7).DoAndReturn(func(ctx context.Context, id int) error {
+		requestID, _ := requestid.FromContext(ctx)
+		require.Equal(t, "request-1", requestID)
+		return nil
+	})
+
+	ctx := metadata.AppendToOutgoingContext(withKey(auth.ScopeClientsWrite), "x-requestid", "request-1")
+	var header metadata.MD
+	_, err := newTestClient(t, mock).DeleteClient(ctx, &clientpb.DeleteClientRequest{Id: 7}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Equal(t, []string{"request-1"}, header.Get("x-requestid"))
+}
+
+func TestServer_audit(t *testing.T) {
+	var records []audit.Record
+	recorder := recorderFunc(func(_ context.Context, r audit.Record) error {
+		records = append(records, r)
+		return nil
+	})
+	mock := mocks.NewMockService(gomock.NewController(t))
+	mock.EXPECT().Delete(gomock.Any(), 7).Return(client.ErrNotFound)
+	mock.EXPECT().Get(gomock.Any(), 7).Return(&client.Entry{ID: 7}, nil)
+
+	c := newTestClientWith(t, mock, recorder, config.RequestLimitConfig{})
+	ctx := metadata.AppendToOutgoingContext(withKey(auth.ScopeClientsWrite), "x-requestid", "request-1")
+	_, err := c.DeleteClient(ctx, &clientpb.DeleteClientRequest{Id: 7})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	// read-only calls are not recorded.
+	_, err = c.GetClient(withKey(auth.ScopeClientsRead), &clientpb.GetClientRequest{Id: 7})
+	require.NoError(t, err)
+
+	require.Equal(t, []audit.Record{{
+		Actor:     "apikey:1",
+		RequestID: "request-1",
+		Action:    "GRPC /vodeno.client.v1.ClientService/DeleteClient",
+		Targets:   []string{"id:7"},
+		Status:    http.StatusNotFound,
+		Result:    audit.ResultFailure,
+	}}, records)
+}
+
+func TestServer_rateLimit(t *testing.T) {
+	mock := mocks.NewMockService(gomock.NewController(t))
+	mock.EXPECT().Get(gomock.Any(), 1).Return(&client.Entry{ID: 1}, nil)
+
+	c := newTestClientWith(t, mock, recorderFunc(func(context.Context, audit.Record) error { return nil }),
+		config.RequestLimitConfig{Groups: []config.RouteLimitConfig{{Group: "clients", Rate: 0.1, Burst: 1}}})
+
+	_, err := c.GetClient(withKey(auth.ScopeClientsRead), &clientpb.GetClientRequest{Id: 1})
+	require.NoError(t, err)
+
+	var header metadata.MD
+	_, err = c.GetClient(withKey(auth.ScopeClientsRead), &clientpb.GetClientRequest{Id: 1}, grpc.Header(&header))
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, []string{"10"}, header.Get("retry-after"))
+}
+
+func TestServer_peerRateLimit(t *testing.T) {
+	mock := mocks.NewMockService(gomock.NewController(t))
+
+	c := newTestClientWith(t, mock, recorderFunc(func(context.Context, audit.Record) error { return nil }),
+		config.RequestLimitConfig{Groups: []config.RouteLimitConfig{{Group: "auth", Rate: 0.1, Burst: 1}}})
+
+	_, err := c.GetClient(withKey("invalid"), &clientpb.GetClientRequest{Id: 1})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// invalid keys are limited before authentication.
+	_, err = c.GetClient(withKey("invalid"), &clientpb.GetClientRequest{Id: 1})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestServer_errors(t *testing.T) {
+	t0 := time.Now().UTC().Truncate(time.Second)
+	valid := &clientpb.Entry{
+		Email: "email@test.com", Title: "title", Content: "content", MailingId: 1, InsertTime: timestamppb.New(t0),
+	}
+
+	for _, tt := range []struct {
+		name         string
+		entry        *clientpb.Entry
+		prep         func(mock *mocks.MockService)
+		wantedCode   codes.Code
+		wantedFields []string
+	}{
+		{
+			name:  "AddsEntry",
+			entry: valid,
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Add(gomock.Any(), client.Entry{
+					Email: "email@test.com", Title: "title", Content: "content", MailingID: 1, InsertTime: t0,
+				})
+			},
+			wantedCode: codes.OK,
+		},
+		{
+			name:  "ReturnsAlreadyExistsOnDuplicate",
+			entry: valid,
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Add(gomock.Any(), gomock.Any()).Return(client.ErrDuplicate)
+			},
+			wantedCode: codes.AlreadyExists,
+		},
+		{
+			name:  "ReturnsFailedPreconditionOnSuppressed",
+			entry: valid,
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Add(gomock.Any(), gomock.Any()).Return(client.ErrSuppressed)
+			},
+			wantedCode: codes.FailedPrecondition,
+		},
+		{
+			name:  "ReturnsInternalOnUnknownError",
+			entry: valid,
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Add(gomock.Any(), gomock.Any()).Return(errors.New("db is down"))
+			},
+			wantedCode: codes.Internal,
+		},
+		{
+			name:  "ReturnsDeadlineExceededOnTimeout",
+			entry: valid,
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Add(gomock.Any(), gomock.Any()).Return(fmt.Errorf("insert: %w", context.DeadlineExceeded))
+			},
+			wantedCode: codes.DeadlineExceeded,
+		},
+		{
+			name:         "ReturnsFieldViolationsOnInvalidEntry",
+			entry:        &clientpb.Entry{Email: "invalid", Title: "title", Content: "content", MailingId: 1},
+			wantedCode:   codes.InvalidArgument,
+			wantedFields: []string{"email", "insert_time"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mocks.NewMockService(gomock.NewController(t))
+			if tt.prep != nil {
+				tt.prep(mock)
+			}
+
+			_, err := newTestClient(t, mock).AddClient(withKey(auth.ScopeClientsWrite), &clientpb.AddClientRequest{Entry: tt.entry})
+			st := status.Convert(err)
+			require.Equal(t, tt.wantedCode, st.Code(), err)
+
+			var fields []string
+			for _, d := range st.Details() {
+				for _, v := range d.(*errdetails.BadRequest).GetFieldViolations() {
+					fields = append(fields, v.GetField())
+				}
+			}
+			require.Equal(t, tt.wantedFields, fields)
+		})
+	}
+}
+
+func TestServer_GetClient(t *testing.T) {
+	mock := mocks.NewMockService(gomock.NewController(t))
+	mock.EXPECT().Get(gomock.Any(), 5).Return(nil, fmt.Errorf("entry %d: %w", 5, client.ErrNotFound))
+
+	_, err := newTestClient(t, mock).GetClient(withKey(auth.ScopeClientsRead), &clientpb.GetClientRequest{Id: 5})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServer_ListClients(t *testing.T) {
+	var entries []client.Entry
+	for i := 1; i <= 250; i++ {
+		entries = append(entries, client.Entry{ID: i})
+	}
+
+	for _, tt := range []struct {
+		name      string
+		request   *clientpb.ListClientsRequest
+		wantedIDs []int64
+	}{
+		{
+			name:      "StreamsAllPages",
+			request:   &clientpb.ListClientsRequest{},
+			wantedIDs: ids(1, 250),
+		},
+		{
+			name:      "StreamsAfterID",
+			request:   &clientpb.ListClientsRequest{AfterId: 240},
+			wantedIDs: ids(241, 250),
+		},
+		{
+			name:      "StopsAtLimit",
+			request:   &clientpb.ListClientsRequest{Limit: 120, AfterId: 10},
+			wantedIDs: ids(11, 130),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mocks.NewMockService(gomock.NewController(t))
+			mock.EXPECT().List(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, cursor client.Cursor) ([]client.Entry, error) {
+					start := 0
+					if cursor.AfterID != nil {
+						start = *cursor.AfterID
+					}
+					end := start + cursor.Limit
+					if end > len(entries) {
+						end = len(entries)
+					}
+					return entries[start:end], nil
+				},
+			).AnyTimes()
+
+			stream, err := newTestClient(t, mock).ListClients(withKey(auth.ScopeClientsRead), tt.request)
+			require.NoError(t, err)
+			var got []int64
+			for {
+				entry, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				got = append(got, entry.GetId())
+			}
+			require.Equal(t, tt.wantedIDs, got)
+		})
+	}
+}
+
+// ids returns IDs from first to last.
+func ids(first, last int64) []int64 {
+	var ids []int64
+	for id := first; id <= last; id++ {
+		ids = append(ids, id)
+	}
+	return ids
+}
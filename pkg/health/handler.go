@@ -0,0 +1,128 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+	statusShutdown    = "shutting down"
+)
+
+// Checker checks whether a dependency of the service works.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is an adapter to use function as Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// check is a named Checker.
+type check struct {
+	name    string
+	checker Checker
+}
+
+// Handler is a http handler of liveness and readiness probes.
+type Handler struct {
+	log          *logrus.Logger
+	timeout      time.Duration
+	checks       []check
+	shuttingDown int32
+}
+
+// NewHandler returns new instance of Handler. Every readiness check must finish in timeout.
+func NewHandler(log *logrus.Logger, timeout time.Duration) *Handler {
+	return &Handler{
+		log:     log,
+		timeout: timeout,
+	}
+}
+
+// AddCheck adds readiness check with given name. It must be called before AddRoutes.
+func (h *Handler) AddCheck(name string, checker Checker) {
+	h.checks = append(h.checks, check{name: name, checker: checker})
+}
+
+// Shutdown makes the service unready, so no new traffic is sent to it during graceful shutdown.
+func (h *Handler) Shutdown() {
+	atomic.StoreInt32(&h.shuttingDown, 1)
+}
+
+// AddRoutes adds probe routes to router. They are public, so they must be added outside of authenticated group.
+func (h *Handler) AddRoutes(router chi.Router) {
+	router.Get("/healthz", h.live)
+	router.Get("/readyz", h.ready)
+}
+
+type readyResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// live reports that the process is able to serve requests, it doesn't check dependencies.
+func (h *Handler) live(w http.ResponseWriter, r *http.Request) {
+	h.write(w, r, http.StatusOK, readyResponse{Status: statusOK, Checks: map[string]string{}})
+}
+
+// ready runs all checks concurrently and reports whether the service can take traffic.
+func (h *Handler) ready(w http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(&h.shuttingDown) == 1 {
+		h.write(w, r, http.StatusServiceUnavailable, readyResponse{Status: statusShutdown, Checks: map[string]string{}})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		resp = readyResponse{Status: statusOK, Checks: make(map[string]string, len(h.checks))}
+	)
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+			result := statusOK
+			if err := c.checker.Check(ctx); err != nil {
+				h.log.WithContext(r.Context()).WithError(err).WithField("check", c.name).Warn("readiness check failed")
+				result = err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Checks[c.name] = result
+			if result != statusOK {
+				resp.Status = statusUnavailable
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if resp.Status != statusOK {
+		status = http.StatusServiceUnavailable
+	}
+	h.write(w, r, status, resp)
+}
+
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, status int, resp readyResponse) {
+	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.log.WithContext(r.Context()).WithError(err).Error("writing JSON to ResponseWriter failed")
+	}
+}
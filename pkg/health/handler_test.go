@@ -0,0 +1,116 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vodeno/pkg/health"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func TestHandler(t *testing.T) {
+	log := logrus.New()
+	log.Out = io.Discard
+
+	ok := health.CheckerFunc(func(context.Context) error { return nil })
+	failing := health.CheckerFunc(func(context.Context) error { return errors.New("connection refused") })
+	slow := health.CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	for _, tt := range []struct {
+		name           string
+		path           string
+		checks         map[string]health.Checker
+		shutdown       bool
+		wantedStatus   int
+		wantedResponse response
+	}{
+		{
+			name:           "LiveIgnoresChecks",
+			path:           "/healthz",
+			checks:         map[string]health.Checker{"db": failing},
+			wantedStatus:   http.StatusOK,
+			wantedResponse: response{Status: "ok", Checks: map[string]string{}},
+		},
+		{
+			name:         "ReadyWhenAllChecksPass",
+			path:         "/readyz",
+			checks:       map[string]health.Checker{"db": ok, "watcher": ok},
+			wantedStatus: http.StatusOK,
+			wantedResponse: response{
+				Status: "ok",
+				Checks: map[string]string{"db": "ok", "watcher": "ok"},
+			},
+		},
+		{
+			name:         "UnreadyWhenCheckFails",
+			path:         "/readyz",
+			checks:       map[string]health.Checker{"db": failing, "watcher": ok},
+			wantedStatus: http.StatusServiceUnavailable,
+			wantedResponse: response{
+				Status: "unavailable",
+				Checks: map[string]string{"db": "connection refused", "watcher": "ok"},
+			},
+		},
+		{
+			name:         "UnreadyWhenCheckTimesOut",
+			path:         "/readyz",
+			checks:       map[string]health.Checker{"db": slow},
+			wantedStatus: http.StatusServiceUnavailable,
+			wantedResponse: response{
+				Status: "unavailable",
+				Checks: map[string]string{"db": context.DeadlineExceeded.Error()},
+			},
+		},
+		{
+			name:           "UnreadyDuringShutdown",
+			path:           "/readyz",
+			checks:         map[string]health.Checker{"db": ok},
+			shutdown:       true,
+			wantedStatus:   http.StatusServiceUnavailable,
+			wantedResponse: response{Status: "shutting down", Checks: map[string]string{}},
+		},
+		{
+			name:           "LiveDuringShutdown",
+			path:           "/healthz",
+			shutdown:       true,
+			wantedStatus:   http.StatusOK,
+			wantedResponse: response{Status: "ok", Checks: map[string]string{}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := health.NewHandler(log, 10*time.Millisecond)
+			for name, c := range tt.checks {
+				h.AddCheck(name, c)
+			}
+			if tt.shutdown {
+				h.Shutdown()
+			}
+			router := chi.NewRouter()
+			h.AddRoutes(router)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			require.Equal(t, tt.wantedStatus, rec.Code)
+
+			var resp response
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			require.Equal(t, tt.wantedResponse, resp)
+		})
+	}
+}
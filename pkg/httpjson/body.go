@@ -0,0 +1,61 @@
+package httpjson
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// MaxBodySize is the default limit of request body size accepted by Decode.
+const MaxBodySize = 1 << 20
+
+// TooLargeError is returned by Body read past its limit.
+type TooLargeError struct {
+	Limit int64
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("request body is larger than %d bytes", e.Limit)
+}
+
+// Body is a request body limited in size, like http.MaxBytesReader, whose error has a type.
+type Body struct {
+	r         io.Reader
+	limit     int64
+	remaining int64
+	err       error // *TooLargeError once read past limit.
+}
+
+// LimitBody returns body of r which fails with *TooLargeError after maxSize bytes.
+func LimitBody(r *http.Request, maxSize int64) *Body {
+	return &Body{r: r.Body, limit: maxSize, remaining: maxSize}
+}
+
+func (b *Body) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	// one byte over the limit tells body which is exactly as large as the limit from a larger one.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.r.Read(p)
+	if int64(n) <= b.remaining {
+		b.remaining -= int64(n)
+		return n, err
+	}
+	n = int(b.remaining)
+	b.remaining = 0
+	b.err = &TooLargeError{Limit: b.limit}
+	return n, b.err
+}
+
+// Err returns *TooLargeError if body was read past its limit, nil otherwise.
+// Parsers may not wrap errors of the reader, e.g. mime/multipart, so it's checked
+// besides their errors.
+func (b *Body) Err() error {
+	return b.err
+}
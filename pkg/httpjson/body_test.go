@@ -0,0 +1,50 @@
+package httpjson_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"vodeno/pkg/httpjson"
+	"vodeno/pkg/problem"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitBody(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		body      string
+		wantedErr bool
+	}{
+		{name: "ReadsSmallerBody", body: "me"},
+		{name: "ReadsBodyOfLimitSize", body: "mes"},
+		{name: "FailsOnLargerBody", body: "message", wantedErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			body := httpjson.LimitBody(httptest.NewRequest(http.MethodPost, "/bounces", strings.NewReader(tt.body)), 3)
+			data, err := io.ReadAll(body)
+			if !tt.wantedErr {
+				require.NoError(t, err)
+				require.NoError(t, body.Err())
+				require.Equal(t, tt.body, string(data))
+				return
+			}
+			require.Equal(t, tt.body[:3], string(data))
+			require.Equal(t, &httpjson.TooLargeError{Limit: 3}, err)
+			require.Equal(t, err, body.Err())
+		})
+	}
+}
+
+func TestProblem_tooLarge(t *testing.T) {
+	body := httpjson.LimitBody(httptest.NewRequest(http.MethodPost, "/bounces", strings.NewReader("message")), 3)
+	_, err := io.ReadAll(body)
+
+	p := httpjson.Problem(fmt.Errorf("multipart: NextPart: %w", err))
+	require.Equal(t, http.StatusRequestEntityTooLarge, p.Status)
+	require.Equal(t, problem.CodeBodyTooLarge, p.Code)
+	require.Equal(t, "request body is larger than 3 bytes", p.Detail)
+}
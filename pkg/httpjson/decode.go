@@ -0,0 +1,144 @@
+// Package httpjson decodes JSON request bodies and limits size of request bodies.
+package httpjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"vodeno/pkg/problem"
+)
+
+// mediaType is a required media type of JSON request bodies.
+const mediaType = "application/json"
+
+// decodeError is an error of Decode, it's turned into problem.Problem by Problem.
+type decodeError struct {
+	status int
+	code   string
+	detail string
+	field  *problem.FieldError
+}
+
+func (e *decodeError) Error() string {
+	return e.detail
+}
+
+// Decode decodes JSON body of r into v, body can't be larger than MaxBodySize.
+// See DecodeLimit.
+func Decode(r *http.Request, v interface{}) error {
+	return DecodeLimit(r, v, MaxBodySize)
+}
+
+// DecodeLimit decodes JSON body of r into v. It requires application/json Content-Type,
+// a body not larger than maxSize bytes with a single JSON value and no fields unknown to v.
+// Returned error describes what's wrong with the body, Problem turns it into problem.Problem.
+func DecodeLimit(r *http.Request, v interface{}, maxSize int64) error {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mt != mediaType {
+		return &decodeError{
+			status: http.StatusUnsupportedMediaType,
+			code:   problem.CodeUnsupportedMediaType,
+			detail: "Content-Type must be " + mediaType,
+		}
+	}
+
+	body := LimitBody(r, maxSize)
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return decodeErrorOf(err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		if err != nil {
+			return decodeErrorOf(err)
+		}
+		return &decodeError{
+			status: http.StatusBadRequest,
+			code:   problem.CodeInvalidJSON,
+			detail: "request body must contain a single JSON value",
+		}
+	}
+	return nil
+}
+
+// Problem returns problem.Problem of request body which couldn't be decoded.
+// Errors of Decode keep their status and code, e.g. 415 on wrong Content-Type,
+// *TooLargeError gets 413.
+func Problem(err error) *problem.Problem {
+	var (
+		decodeErr *decodeError
+		tooLarge  *TooLargeError
+	)
+	switch {
+	case errors.As(err, &decodeErr):
+		p := problem.New(decodeErr.status, decodeErr.code, decodeErr.detail)
+		if decodeErr.field != nil {
+			p.Errors = []problem.FieldError{*decodeErr.field}
+		}
+		return p
+	case errors.As(err, &tooLarge):
+		return problem.New(http.StatusRequestEntityTooLarge, problem.CodeBodyTooLarge, tooLarge.Error())
+	default:
+		return problem.New(http.StatusBadRequest, problem.CodeInvalidJSON, err.Error())
+	}
+}
+
+// decodeErrorOf describes error returned by json.Decoder.
+func decodeErrorOf(err error) *decodeError {
+	e := &decodeError{status: http.StatusBadRequest, code: problem.CodeInvalidJSON}
+
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		tooLarge  *TooLargeError
+	)
+	switch {
+	case errors.Is(err, io.EOF):
+		e.detail = "request body is empty"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		e.detail = "request body is not complete JSON"
+	case errors.As(err, &syntaxErr):
+		e.detail = fmt.Sprintf("invalid JSON at offset %d: %s", syntaxErr.Offset, syntaxErr)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		e.detail = "request body has a field of wrong type"
+		e.field = &problem.FieldError{Field: typeErr.Field, Code: "type", Message: "must be " + typeName(typeErr.Type)}
+	case errors.As(err, &typeErr):
+		e.detail = "request body must be " + typeName(typeErr.Type)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no error type of unknown fields.
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		e.detail = "request body has an unknown field"
+		e.field = &problem.FieldError{Field: field, Code: "unknown", Message: "field is not allowed"}
+	case errors.As(err, &tooLarge):
+		e.status = http.StatusRequestEntityTooLarge
+		e.code = problem.CodeBodyTooLarge
+		e.detail = tooLarge.Error()
+	default:
+		e.detail = err.Error()
+	}
+	return e
+}
+
+// typeName returns name of JSON type decoded into t.
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "a base64 string"
+		}
+		return "an array"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	default:
+		return "a number"
+	}
+}
@@ -0,0 +1,137 @@
+package httpjson_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"vodeno/pkg/httpjson"
+	"vodeno/pkg/problem"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeLimit(t *testing.T) {
+	type entry struct {
+		MailingID int    `json:"mailing_id"`
+		Data      []byte `json:"data"`
+	}
+
+	for _, tt := range []struct {
+		name         string
+		contentType  string
+		body         string
+		wantedStatus int
+		wantedCode   string
+		wantedDetail string
+		wantedErrors []problem.FieldError
+	}{
+		{
+			name:        "Decodes",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"mailing_id": 5}`,
+		},
+		{
+			name:         "Returns415OnOtherContentType",
+			contentType:  "text/plain",
+			body:         `{"mailing_id": 5}`,
+			wantedStatus: http.StatusUnsupportedMediaType,
+			wantedCode:   problem.CodeUnsupportedMediaType,
+			wantedDetail: "Content-Type must be application/json",
+		},
+		{
+			name:         "Returns415WithoutContentType",
+			body:         `{"mailing_id": 5}`,
+			wantedStatus: http.StatusUnsupportedMediaType,
+			wantedCode:   problem.CodeUnsupportedMediaType,
+			wantedDetail: "Content-Type must be application/json",
+		},
+		{
+			name:         "Returns413OnLargeBody",
+			contentType:  "application/json",
+			body:         `{"data": "` + strings.Repeat("a", 100) + `"}`,
+			wantedStatus: http.StatusRequestEntityTooLarge,
+			wantedCode:   problem.CodeBodyTooLarge,
+			wantedDetail: "request body is larger than 64 bytes",
+		},
+		{
+			name:         "ReportsUnknownField",
+			contentType:  "application/json",
+			body:         `{"mailingId": 5}`,
+			wantedStatus: http.StatusBadRequest,
+			wantedCode:   problem.CodeInvalidJSON,
+			wantedDetail: "request body has an unknown field",
+			wantedErrors: []problem.FieldError{{Field: "mailingId", Code: "unknown", Message: "field is not allowed"}},
+		},
+		{
+			name:         "ReportsFieldOfWrongType",
+			contentType:  "application/json",
+			body:         `{"mailing_id": "5"}`,
+			wantedStatus: http.StatusBadRequest,
+			wantedCode:   problem.CodeInvalidJSON,
+			wantedDetail: "request body has a field of wrong type",
+			wantedErrors: []problem.FieldError{{Field: "mailing_id", Code: "type", Message: "must be a number"}},
+		},
+		{
+			name:         "ReportsBodyOfWrongType",
+			contentType:  "application/json",
+			body:         `[1]`,
+			wantedStatus: http.StatusBadRequest,
+			wantedCode:   problem.CodeInvalidJSON,
+			wantedDetail: "request body must be an object",
+		},
+		{
+			name:         "ReportsSyntaxError",
+			contentType:  "application/json",
+			body:         `{"mailing_id": 5,}`,
+			wantedStatus: http.StatusBadRequest,
+			wantedCode:   problem.CodeInvalidJSON,
+			wantedDetail: "invalid JSON at offset 18: invalid character '}' looking for beginning of object key string",
+		},
+		{
+			name:         "ReportsEmptyBody",
+			contentType:  "application/json",
+			wantedStatus: http.StatusBadRequest,
+			wantedCode:   problem.CodeInvalidJSON,
+			wantedDetail: "request body is empty",
+		},
+		{
+			name:         "ReportsTruncatedBody",
+			contentType:  "application/json",
+			body:         `{"mailing_id": 5`,
+			wantedStatus: http.StatusBadRequest,
+			wantedCode:   problem.CodeInvalidJSON,
+			wantedDetail: "request body is not complete JSON",
+		},
+		{
+			name:         "ReportsMultipleValues",
+			contentType:  "application/json",
+			body:         `{"mailing_id": 5} {"mailing_id": 6}`,
+			wantedStatus: http.StatusBadRequest,
+			wantedCode:   problem.CodeInvalidJSON,
+			wantedDetail: "request body must contain a single JSON value",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/clients", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			var e entry
+			err := httpjson.DecodeLimit(req, &e, 64)
+			if tt.wantedStatus == 0 {
+				require.NoError(t, err)
+				require.Equal(t, 5, e.MailingID)
+				return
+			}
+			require.Error(t, err)
+
+			p := httpjson.Problem(err)
+			require.Equal(t, tt.wantedStatus, p.Status)
+			require.Equal(t, tt.wantedCode, p.Code)
+			require.Equal(t, tt.wantedDetail, p.Detail)
+			require.Equal(t, tt.wantedErrors, p.Errors)
+		})
+	}
+}
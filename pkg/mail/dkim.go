@@ -0,0 +1,136 @@
+package mail
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"vodeno/pkg/config"
+
+	"github.com/emersion/go-msgauth/dkim"
+)
+
+// defaultSignedHeaders are signed when config doesn't specify any, RFC 6376 section 5.4.1.
+// List-Unsubscribe headers must be signed for one-click unsubscribe to work, RFC 8058.
+var defaultSignedHeaders = []string{
+	"From", "Reply-To", "Subject", "Date", "To", "Cc", "Message-ID",
+	"MIME-Version", "Content-Type", "Content-Transfer-Encoding",
+	"List-Unsubscribe", "List-Unsubscribe-Post",
+}
+
+// ErrInvalidKey is returned when DKIM private key can't be used for signing.
+var ErrInvalidKey = errors.New("invalid DKIM private key")
+
+// Signer signs built messages before delivery.
+type Signer interface {
+	// Sign returns signed message. from is an envelope sender address.
+	Sign(from string, msg []byte) ([]byte, error)
+}
+
+// DKIMSigner adds DKIM-Signature header to messages.
+// Key and selector are chosen based on sender's domain,
+// messages from domains without configured key are left unsigned.
+type DKIMSigner struct {
+	domains map[string]*dkim.SignOptions
+}
+
+// NewDKIMSigner creates new instance of DKIMSigner.
+// It loads private keys of all configured domains.
+func NewDKIMSigner(cfg config.DKIMConfig) (*DKIMSigner, error) {
+	s := &DKIMSigner{domains: make(map[string]*dkim.SignOptions, len(cfg.Domains))}
+	for _, d := range cfg.Domains {
+		key, err := loadKey(d)
+		if err != nil {
+			return nil, fmt.Errorf("domain %s: %w", d.Domain, err)
+		}
+
+		headers := d.Headers
+		if len(headers) == 0 {
+			headers = cfg.Headers
+		}
+		if len(headers) == 0 {
+			headers = defaultSignedHeaders
+		}
+		if !containsFold(headers, "From") {
+			return nil, fmt.Errorf("domain %s: From header must be signed", d.Domain)
+		}
+
+		s.domains[strings.ToLower(d.Domain)] = &dkim.SignOptions{
+			Domain:                 d.Domain,
+			Selector:               d.Selector,
+			Signer:                 key,
+			HeaderCanonicalization: dkim.CanonicalizationRelaxed,
+			BodyCanonicalization:   dkim.CanonicalizationRelaxed,
+			HeaderKeys:             headers,
+		}
+	}
+	return s, nil
+}
+
+// Sign signs message with a key of sender's domain.
+func (s *DKIMSigner) Sign(from string, msg []byte) ([]byte, error) {
+	opts, ok := s.domains[domain(from)]
+	if !ok {
+		return msg, nil
+	}
+
+	var b bytes.Buffer
+	if err := dkim.Sign(&b, bytes.NewReader(msg), opts); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// loadKey loads domain's private key from file or config value.
+func loadKey(cfg config.DKIMDomainConfig) (crypto.Signer, error) {
+	data := []byte(cfg.Key)
+	if cfg.KeyFile != "" {
+		var err error
+		if data, err = os.ReadFile(cfg.KeyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidKey
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKey, err)
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case ed25519.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%w: only RSA and Ed25519 keys are supported", ErrInvalidKey)
+	}
+}
+
+// domain returns lower-cased domain part of an address.
+func domain(addr string) string {
+	if i := strings.LastIndex(addr, "@"); i >= 0 {
+		addr = addr[i+1:]
+	}
+	return strings.ToLower(addr)
+}
+
+func containsFold(ss []string, s string) bool {
+	for _, v := range ss {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package mail_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"vodeno/pkg/config"
+	mail2 "vodeno/pkg/mail"
+
+	"github.com/emersion/go-msgauth/dkim"
+	"github.com/stretchr/testify/require"
+)
+
+// dkimKeys generates RSA and Ed25519 keys. It returns PEM encoded private keys
+// and DNS TXT records with public keys.
+func dkimKeys(t *testing.T) (rsaPEM, edPEM []byte, rsaTXT, edTXT string) {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	rsaPub, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	rsaTXT = "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(rsaPub)
+
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	edPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDER})
+	edTXT = "v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(edPub)
+	return
+}
+
+func TestDKIMSigner_Sign(t *testing.T) {
+	rsaPEM, edPEM, rsaTXT, edTXT := dkimKeys(t)
+
+	keyFile := filepath.Join(t.TempDir(), "rsa.pem")
+	require.NoError(t, os.WriteFile(keyFile, rsaPEM, 0600))
+
+	signer, err := mail2.NewDKIMSigner(config.DKIMConfig{
+		Headers: []string{"From", "To", "Subject"},
+		Domains: []config.DKIMDomainConfig{
+			{Domain: "vodeno.com", Selector: "rsa", KeyFile: keyFile},
+			{Domain: "mail.vodeno.com", Selector: "ed", Key: string(edPEM), Headers: []string{"From", "Subject", "Message-ID"}},
+		},
+	})
+	require.NoError(t, err)
+
+	records := map[string]string{
+		"rsa._domainkey.vodeno.com":     rsaTXT,
+		"ed._domainkey.mail.vodeno.com": edTXT,
+	}
+	verify := func(t *testing.T, msg []byte) []*dkim.Verification {
+		verifications, err := dkim.VerifyWithOptions(bytes.NewReader(msg), &dkim.VerifyOptions{
+			LookupTXT: func(domain string) ([]string, error) {
+				txt, ok := records[domain]
+				if !ok {
+					return nil, fmt.Errorf("no TXT record for %s", domain)
+				}
+				return []string{txt}, nil
+			},
+		})
+		require.NoError(t, err)
+		return verifications
+	}
+
+	for _, tt := range []struct {
+		name          string
+		from          string
+		wantedDomain  string
+		wantedHeaders []string
+	}{
+		{
+			name:          "RSA",
+			from:          "no-reply@vodeno.com",
+			wantedDomain:  "vodeno.com",
+			wantedHeaders: []string{"From", "To", "Subject"},
+		},
+		{
+			name:          "Ed25519",
+			from:          "no-reply@Mail.Vodeno.com",
+			wantedDomain:  "mail.vodeno.com",
+			wantedHeaders: []string{"From", "Subject", "Message-ID"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := (&mail2.Message{
+				From:    tt.from,
+				To:      []string{"to@test.com"},
+				Subject: "Zażółć gęślą jaźń",
+				HTML:    "<p>content</p>",
+			}).Bytes()
+			require.NoError(t, err)
+
+			signed, err := signer.Sign(tt.from, msg)
+			require.NoError(t, err)
+
+			verifications := verify(t, signed)
+			require.Len(t, verifications, 1)
+			require.NoError(t, verifications[0].Err)
+			require.Equal(t, tt.wantedDomain, verifications[0].Domain)
+			require.Equal(t, tt.wantedHeaders, verifications[0].HeaderKeys)
+
+			// Any modification breaks the signature.
+			tampered := bytes.Replace(signed, []byte("content"), []byte("contents"), 1)
+			verifications = verify(t, tampered)
+			require.Len(t, verifications, 1)
+			require.Error(t, verifications[0].Err)
+		})
+	}
+
+	t.Run("LeavesUnknownDomainUnsigned", func(t *testing.T) {
+		msg := []byte("From: from@other.com\r\nTo: to@test.com\r\n\r\ncontent")
+		signed, err := signer.Sign("from@other.com", msg)
+		require.NoError(t, err)
+		require.Equal(t, msg, signed)
+	})
+}
+
+func TestNewDKIMSigner(t *testing.T) {
+	_, edPEM, _, _ := dkimKeys(t)
+
+	for _, tt := range []struct {
+		name string
+		cfg  config.DKIMConfig
+	}{
+		{
+			name: "ReturnsErrorOnInvalidKey",
+			cfg: config.DKIMConfig{Domains: []config.DKIMDomainConfig{
+				{Domain: "vodeno.com", Selector: "s", Key: "not a key"},
+			}},
+		},
+		{
+			name: "ReturnsErrorOnMissingKeyFile",
+			cfg: config.DKIMConfig{Domains: []config.DKIMDomainConfig{
+				{Domain: "vodeno.com", Selector: "s", KeyFile: "/does/not/exist.pem"},
+			}},
+		},
+		{
+			name: "ReturnsErrorWhenFromIsNotSigned",
+			cfg: config.DKIMConfig{
+				Headers: []string{"Subject"},
+				Domains: []config.DKIMDomainConfig{{Domain: "vodeno.com", Selector: "s", Key: string(edPEM)}},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mail2.NewDKIMSigner(tt.cfg)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package mail
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"vodeno/pkg/config"
+)
+
+const globalLimitKey = "global" // rate limit key shared by all messages.
+
+// Limiter enforces limits shared by all instances of the service.
+type Limiter interface {
+	// Wait blocks until another message with given key can be sent
+	// without exceeding rate messages per second.
+	Wait(ctx context.Context, key string, rate int) error
+	// Acquire blocks until one of concurrency slots of given key is free.
+	// Returned function releases the slot.
+	Acquire(ctx context.Context, key string, concurrency int) (release func(), err error)
+}
+
+// LimitedTransport is a Transport which enforces global and per recipient domain limits.
+type LimitedTransport struct {
+	transport Transport
+	limiter   Limiter
+	global    int
+	fallback  config.DomainLimitConfig
+	domains   map[string]config.DomainLimitConfig
+}
+
+// NewLimitedTransport creates new instance of LimitedTransport wrapping given Transport.
+func NewLimitedTransport(transport Transport, limiter Limiter, cfg config.RateLimitConfig) *LimitedTransport {
+	domains := make(map[string]config.DomainLimitConfig, len(cfg.Domains))
+	for _, d := range cfg.Domains {
+		domains[strings.ToLower(d.Domain)] = d
+	}
+	return &LimitedTransport{
+		transport: transport,
+		limiter:   limiter,
+		global:    cfg.Global,
+		fallback:  cfg.Default,
+		domains:   domains,
+	}
+}
+
+// Deliver waits until message can be sent within limits and delivers it.
+func (t *LimitedTransport) Deliver(ctx context.Context, from string, to []string, msg []byte) error {
+	// Slots are acquired in the same order by all messages to avoid deadlocks.
+	domains := make([]string, 0, len(to))
+	seen := make(map[string]bool, len(to))
+	for _, rcpt := range to {
+		if d := domain(rcpt); !seen[d] {
+			seen[d] = true
+			domains = append(domains, d)
+		}
+	}
+	sort.Strings(domains)
+
+	for _, d := range domains {
+		limit, ok := t.domains[d]
+		if !ok {
+			limit = t.fallback
+		}
+		key := "domain:" + d
+
+		if limit.Concurrency > 0 {
+			release, err := t.limiter.Acquire(ctx, key, limit.Concurrency)
+			if err != nil {
+				return err
+			}
+			defer release()
+		}
+		if limit.Rate > 0 {
+			if err := t.limiter.Wait(ctx, key, limit.Rate); err != nil {
+				return err
+			}
+		}
+	}
+
+	if t.global > 0 {
+		if err := t.limiter.Wait(ctx, globalLimitKey, t.global); err != nil {
+			return err
+		}
+	}
+	return t.transport.Deliver(ctx, from, to, msg)
+}
@@ -0,0 +1,99 @@
+package mail_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"vodeno/pkg/config"
+	mail2 "vodeno/pkg/mail"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingLimiter records calls to Limiter.
+type recordingLimiter struct {
+	calls []string
+	err   error
+}
+
+func (l *recordingLimiter) Wait(_ context.Context, key string, rate int) error {
+	l.calls = append(l.calls, fmt.Sprintf("wait %s %d", key, rate))
+	return l.err
+}
+
+func (l *recordingLimiter) Acquire(_ context.Context, key string, concurrency int) (func(), error) {
+	l.calls = append(l.calls, fmt.Sprintf("acquire %s %d", key, concurrency))
+	return func() { l.calls = append(l.calls, "release "+key) }, l.err
+}
+
+func TestLimitedTransport_Deliver(t *testing.T) {
+	cfg := config.RateLimitConfig{
+		Global:  50,
+		Default: config.DomainLimitConfig{Rate: 5, Concurrency: 2},
+		Domains: []config.DomainLimitConfig{
+			{Domain: "Gmail.com", Rate: 10, Concurrency: 5},
+			{Domain: "unlimited.com"},
+		},
+	}
+
+	for _, tt := range []struct {
+		name          string
+		to            []string
+		limiterErr    error
+		wantedCalls   []string
+		wantDelivered bool
+	}{
+		{
+			name: "DefaultLimits",
+			to:   []string{"client@example.com"},
+			wantedCalls: []string{
+				"acquire domain:example.com 2",
+				"wait domain:example.com 5",
+				"wait global 50",
+				"deliver",
+				"release domain:example.com",
+			},
+			wantDelivered: true,
+		},
+		{
+			name: "DomainLimits",
+			to:   []string{"client@GMAIL.com", "other@unlimited.com", "another@gmail.com"},
+			wantedCalls: []string{
+				"acquire domain:gmail.com 5",
+				"wait domain:gmail.com 10",
+				"wait global 50",
+				"deliver",
+				"release domain:gmail.com",
+			},
+			wantDelivered: true,
+		},
+		{
+			name:        "ReturnsErrorWhenLimiterFails",
+			to:          []string{"client@example.com"},
+			limiterErr:  context.DeadlineExceeded,
+			wantedCalls: []string{"acquire domain:example.com 2"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &recordingLimiter{err: tt.limiterErr}
+			var delivered bool
+			transport := mail2.NewLimitedTransport(transportFunc(
+				func(ctx context.Context, from string, to []string, msg []byte) error {
+					delivered = true
+					limiter.calls = append(limiter.calls, "deliver")
+					return nil
+				},
+			), limiter, cfg)
+
+			err := transport.Deliver(context.Background(), "from@vodeno.com", tt.to, []byte("msg"))
+			if tt.limiterErr != nil {
+				require.True(t, errors.Is(err, tt.limiterErr))
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.wantDelivered, delivered)
+			require.Equal(t, tt.wantedCalls, limiter.calls)
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxLineLength = 76 // maximum length of base64 encoded line, RFC 2045.
+	maxHeaderLine = 78 // recommended maximum length of header line, RFC 5322.
+	crlf          = "\r\n"
+
+	defaultAttachmentType = "application/octet-stream"
+)
+
+var (
+	// ErrNoSender is returned when message has no From address.
+	ErrNoSender = errors.New("message has no sender")
+	// ErrNoRecipients is returned when message has no To addresses.
+	ErrNoRecipients = errors.New("message has no recipients")
+)
+
+// Attachment is a file attached to a Message.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	// ContentID identifies inline attachment so it can be referenced
+	// from HTML body with cid: URL, e.g. <img src="cid:logo">.
+	ContentID string
+	Data      []byte
+}
+
+// Message represents an e-mail message.
+//
+// Message is encoded as follows:
+//
+//	multipart/mixed              - only when there are attachments
+//	  multipart/related          - only when there are inline attachments
+//	    multipart/alternative    - only when there is HTML body
+//	      text/plain
+//	      text/html
+//	    inline attachments
+//	  attachments
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	// HTML is an HTML body of the message.
+	HTML string
+	// Text is a plain-text body of the message.
+	// If empty, it's generated from HTML.
+	Text string
+	// Header contains additional headers, e.g. Reply-To.
+	// Date and Message-ID are generated when not set.
+	Header      textproto.MIMEHeader
+	Inline      []Attachment
+	Attachments []Attachment
+}
+
+// Bytes builds message in RFC 5322 format.
+func (m *Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := m.write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Sender returns message sender address.
+func (m *Message) Sender() (*mail.Address, error) {
+	if m.From == "" {
+		return nil, ErrNoSender
+	}
+	return mail.ParseAddress(m.From)
+}
+
+// Recipients returns message recipients addresses.
+func (m *Message) Recipients() ([]*mail.Address, error) {
+	if len(m.To) == 0 {
+		return nil, ErrNoRecipients
+	}
+	addrs := make([]*mail.Address, 0, len(m.To))
+	for _, to := range m.To {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+func (m *Message) write(w io.Writer) error {
+	from, err := m.Sender()
+	if err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+	to, err := m.Recipients()
+	if err != nil {
+		return fmt.Errorf("invalid recipients: %w", err)
+	}
+
+	recipients := make([]string, 0, len(to))
+	for _, addr := range to {
+		recipients = append(recipients, addr.String())
+	}
+
+	body := m.body()
+
+	header := textproto.MIMEHeader{}
+	for k, v := range m.Header {
+		header[textproto.CanonicalMIMEHeaderKey(k)] = v
+	}
+	if header.Get("Date") == "" {
+		header.Set("Date", time.Now().Format(time.RFC1123Z))
+	}
+	if header.Get("Message-Id") == "" {
+		id, err := messageID(from.Address)
+		if err != nil {
+			return err
+		}
+		header.Set("Message-Id", id)
+	}
+
+	// Well known headers go first, the rest is sorted to keep output stable.
+	lines := []string{
+		foldHeader("From", from.String()),
+		foldHeader("To", strings.Join(recipients, ", ")),
+		foldHeader("Subject", encodeHeader(m.Subject)),
+		foldHeader("Date", header.Get("Date")),
+		foldHeader("Message-ID", header.Get("Message-Id")),
+		foldHeader("MIME-Version", "1.0"),
+	}
+	header.Del("Date")
+	header.Del("Message-Id")
+
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			lines = append(lines, foldHeader(k, encodeHeader(v)))
+		}
+	}
+
+	partHeader := body.prepare()
+	partKeys := make([]string, 0, len(partHeader))
+	for k := range partHeader {
+		partKeys = append(partKeys, k)
+	}
+	sort.Strings(partKeys)
+	for _, k := range partKeys {
+		lines = append(lines, foldHeader(k, partHeader.Get(k)))
+	}
+
+	if _, err := io.WriteString(w, strings.Join(lines, crlf)+crlf+crlf); err != nil {
+		return err
+	}
+	return body.writeBody(w)
+}
+
+// body returns root MIME entity of the message.
+func (m *Message) body() *entity {
+	text := m.Text
+	if text == "" && m.HTML != "" {
+		text = HTMLToText(m.HTML)
+	}
+
+	root := textEntity("text/plain", text)
+	if m.HTML != "" {
+		root = &entity{
+			contentType: "multipart/alternative",
+			parts:       []*entity{root, textEntity("text/html", m.HTML)},
+		}
+	}
+
+	if len(m.Inline) > 0 {
+		parts := []*entity{root}
+		for _, a := range m.Inline {
+			parts = append(parts, attachmentEntity(a, "inline"))
+		}
+		root = &entity{contentType: "multipart/related", parts: parts}
+	}
+
+	if len(m.Attachments) > 0 {
+		parts := []*entity{root}
+		for _, a := range m.Attachments {
+			parts = append(parts, attachmentEntity(a, "attachment"))
+		}
+		root = &entity{contentType: "multipart/mixed", parts: parts}
+	}
+	return root
+}
+
+// entity is a single MIME entity. It's either a leaf with body or a multipart container.
+type entity struct {
+	contentType string
+	header      textproto.MIMEHeader
+	body        func(w io.Writer) error
+	parts       []*entity
+	boundary    string
+}
+
+// prepare returns entity headers. Multipart entities get their boundary assigned.
+func (e *entity) prepare() textproto.MIMEHeader {
+	h := textproto.MIMEHeader{}
+	for k, v := range e.header {
+		h[k] = v
+	}
+	if len(e.parts) == 0 {
+		h.Set("Content-Type", e.contentType)
+		return h
+	}
+	e.boundary = randomBoundary()
+	h.Set("Content-Type", mime.FormatMediaType(e.contentType, map[string]string{"boundary": e.boundary}))
+	return h
+}
+
+// writeBody writes entity body. prepare must be called first.
+func (e *entity) writeBody(w io.Writer) error {
+	if len(e.parts) == 0 {
+		return e.body(w)
+	}
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(e.boundary); err != nil {
+		return err
+	}
+	for _, p := range e.parts {
+		pw, err := mw.CreatePart(p.prepare())
+		if err != nil {
+			return err
+		}
+		if err := p.writeBody(pw); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// textEntity returns quoted-printable encoded text entity.
+func textEntity(mediaType, text string) *entity {
+	return &entity{
+		contentType: mime.FormatMediaType(mediaType, map[string]string{"charset": "utf-8"}),
+		header:      textproto.MIMEHeader{"Content-Transfer-Encoding": {"quoted-printable"}},
+		body: func(w io.Writer) error {
+			qw := quotedprintable.NewWriter(w)
+			if _, err := io.WriteString(qw, text); err != nil {
+				return err
+			}
+			return qw.Close()
+		},
+	}
+}
+
+// attachmentEntity returns base64 encoded attachment entity with given disposition.
+func attachmentEntity(a Attachment, disposition string) *entity {
+	contentType := defaultAttachmentType
+	if mediaType, params, err := mime.ParseMediaType(a.ContentType); err == nil {
+		contentType = mime.FormatMediaType(mediaType, params)
+	}
+
+	header := textproto.MIMEHeader{"Content-Transfer-Encoding": {"base64"}}
+	if a.Filename != "" {
+		header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+	} else {
+		header.Set("Content-Disposition", disposition)
+	}
+	if a.ContentID != "" {
+		header.Set("Content-Id", "<"+strings.Trim(a.ContentID, "<>")+">")
+	}
+
+	return &entity{
+		contentType: contentType,
+		header:      header,
+		body: func(w io.Writer) error {
+			encoded := base64.StdEncoding.EncodeToString(a.Data)
+			for len(encoded) > 0 {
+				n := maxLineLength
+				if len(encoded) < n {
+					n = len(encoded)
+				}
+				if _, err := io.WriteString(w, encoded[:n]+crlf); err != nil {
+					return err
+				}
+				encoded = encoded[n:]
+			}
+			return nil
+		},
+	}
+}
+
+// encodeHeader encodes header value with RFC 2047 if it contains non-ASCII characters.
+func encodeHeader(s string) string {
+	return mime.QEncoding.Encode("utf-8", s)
+}
+
+// foldHeader formats header line folding it on whitespaces when it's too long.
+func foldHeader(name, value string) string {
+	var (
+		b       strings.Builder
+		lineLen = len(name) + 1
+	)
+	b.WriteString(name)
+	b.WriteString(":")
+	for _, word := range strings.Split(value, " ") {
+		if lineLen > 0 && lineLen+len(word)+1 > maxHeaderLine {
+			b.WriteString(crlf)
+			lineLen = 0
+		}
+		b.WriteString(" ")
+		b.WriteString(word)
+		lineLen += len(word) + 1
+	}
+	return b.String()
+}
+
+// messageID generates unique Message-ID using sender's domain.
+func messageID(from string) (string, error) {
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("<%d.%x@%s>", time.Now().UnixNano(), buf, domain), nil
+}
+
+// randomBoundary generates random multipart boundary.
+// It's short enough to keep Content-Type header lines within 78 characters.
+func randomBoundary() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%x", buf)
+}
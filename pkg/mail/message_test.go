@@ -0,0 +1,266 @@
+package mail_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	mail2 "vodeno/pkg/mail"
+	"vodeno/pkg/requestid"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// part is a decoded MIME part.
+type part struct {
+	mediaType string
+	header    textproto.MIMEHeader
+	body      string
+}
+
+// parseMessage parses message and returns its headers and flattened leaf parts.
+func parseMessage(t *testing.T, b []byte) (mail.Header, []part) {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	parts := parseEntity(t, textproto.MIMEHeader(msg.Header), msg.Body)
+	return msg.Header, parts
+}
+
+func parseEntity(t *testing.T, header textproto.MIMEHeader, body io.Reader) []part {
+	t.Helper()
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	require.NoError(t, err)
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		parts := []part{{mediaType: mediaType, header: header}}
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			parts = append(parts, parseEntity(t, p.Header, p)...)
+		}
+		return parts
+	}
+
+	var r io.Reader
+	switch header.Get("Content-Transfer-Encoding") {
+	case "quoted-printable":
+		r = quotedprintable.NewReader(body)
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, body)
+	default:
+		r = body
+	}
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return []part{{mediaType: mediaType, header: header, body: string(b)}}
+}
+
+func TestMessage_Bytes(t *testing.T) {
+	t.Run("ReturnsErrorOnMissingSender", func(t *testing.T) {
+		_, err := (&mail2.Message{To: []string{"to@test.com"}}).Bytes()
+		require.ErrorIs(t, err, mail2.ErrNoSender)
+	})
+
+	t.Run("ReturnsErrorOnMissingRecipients", func(t *testing.T) {
+		_, err := (&mail2.Message{From: "from@test.com"}).Bytes()
+		require.ErrorIs(t, err, mail2.ErrNoRecipients)
+	})
+
+	t.Run("PlainText", func(t *testing.T) {
+		b, err := (&mail2.Message{
+			From:    "from@test.com",
+			To:      []string{"to@test.com"},
+			Subject: "title",
+			Text:    "content",
+		}).Bytes()
+		require.NoError(t, err)
+
+		header, parts := parseMessage(t, b)
+		require.Equal(t, "<from@test.com>", header.Get("From"))
+		require.Equal(t, "<to@test.com>", header.Get("To"))
+		require.Equal(t, "title", header.Get("Subject"))
+		require.Equal(t, "1.0", header.Get("MIME-Version"))
+		require.NotEmpty(t, header.Get("Date"))
+		require.True(t, strings.HasSuffix(header.Get("Message-ID"), "@test.com>"))
+
+		require.Len(t, parts, 1)
+		require.Equal(t, "text/plain", parts[0].mediaType)
+		require.Equal(t, "content", parts[0].body)
+	})
+
+	t.Run("EncodesNonASCIIHeaders", func(t *testing.T) {
+		subject := "Zażółć gęślą jaźń – a very long title which has to be folded into multiple lines"
+		b, err := (&mail2.Message{
+			From:    "Wysyłka <from@test.com>",
+			To:      []string{"to@test.com"},
+			Subject: subject,
+			Text:    "content",
+			Header:  textproto.MIMEHeader{"X-Campaign": {"Promocja"}},
+		}).Bytes()
+		require.NoError(t, err)
+
+		headers := strings.SplitN(string(b), "\r\n\r\n", 2)[0]
+		for _, line := range strings.Split(headers, "\r\n") {
+			require.LessOrEqual(t, len(line), 78, line)
+			for _, r := range line {
+				require.Less(t, r, rune(128), line)
+			}
+		}
+
+		header, _ := parseMessage(t, b)
+		dec := new(mime.WordDecoder)
+		got, err := dec.DecodeHeader(header.Get("Subject"))
+		require.NoError(t, err)
+		require.Equal(t, subject, got)
+
+		from, err := header.AddressList("From")
+		require.NoError(t, err)
+		require.Equal(t, "Wysyłka", from[0].Name)
+		require.Equal(t, "Promocja", header.Get("X-Campaign"))
+	})
+
+	t.Run("HTMLWithAttachments", func(t *testing.T) {
+		html := `<p>Zażółć <img src="cid:logo" alt="logo"></p>` + strings.Repeat("x", 100)
+		b, err := (&mail2.Message{
+			From:    "from@test.com",
+			To:      []string{"to@test.com"},
+			Subject: "title",
+			HTML:    html,
+			Inline: []mail2.Attachment{
+				{Filename: "logo.png", ContentType: "image/png", ContentID: "logo", Data: []byte("png")},
+			},
+			Attachments: []mail2.Attachment{
+				{Filename: "cennik.pdf", ContentType: "application/pdf", Data: bytes.Repeat([]byte{0, 1, 2}, 100)},
+				{Filename: "żółw.txt", Data: []byte("text")},
+			},
+		}).Bytes()
+		require.NoError(t, err)
+
+		for _, line := range strings.Split(string(b), "\r\n") {
+			require.LessOrEqual(t, len(line), 78, line)
+		}
+
+		_, parts := parseMessage(t, b)
+		var types []string
+		for _, p := range parts {
+			types = append(types, p.mediaType)
+		}
+		require.Equal(t, []string{
+			"multipart/mixed",
+			"multipart/related",
+			"multipart/alternative",
+			"text/plain",
+			"text/html",
+			"image/png",
+			"application/pdf",
+			"application/octet-stream",
+		}, types)
+
+		require.Equal(t, "Zażółć logo\r\n\r\n"+strings.Repeat("x", 100), parts[3].body)
+		require.Equal(t, html, parts[4].body)
+
+		require.Equal(t, "<logo>", parts[5].header.Get("Content-Id"))
+		require.Equal(t, "png", parts[5].body)
+		disposition, params, err := mime.ParseMediaType(parts[5].header.Get("Content-Disposition"))
+		require.NoError(t, err)
+		require.Equal(t, "inline", disposition)
+		require.Equal(t, "logo.png", params["filename"])
+
+		require.Equal(t, string(bytes.Repeat([]byte{0, 1, 2}, 100)), parts[6].body)
+
+		disposition, params, err = mime.ParseMediaType(parts[7].header.Get("Content-Disposition"))
+		require.NoError(t, err)
+		require.Equal(t, "attachment", disposition)
+		require.Equal(t, "żółw.txt", params["filename"])
+		require.Equal(t, "text", parts[7].body)
+	})
+}
+
+func TestHTMLToText(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "Paragraphs",
+			html: "<html><head><title>t</title><style>p {}</style></head><body><h1>Hello</h1>\n<p>first\n   line</p><p>second<br>line</p></body></html>",
+			want: "Hello\n\nfirst line\n\nsecond\nline",
+		},
+		{
+			name: "Lists",
+			html: "<ul><li>one</li><li> two </li></ul>",
+			want: "* one\n* two",
+		},
+		{
+			name: "Links",
+			html: `<a href="https://vodeno.com">Vodeno</a> <a href="https://vodeno.com">https://vodeno.com</a> <a href="#top">top</a>`,
+			want: "Vodeno (https://vodeno.com) https://vodeno.com top",
+		},
+		{
+			name: "Preformatted",
+			html: "<pre>a\n  b</pre>",
+			want: "a\n  b",
+		},
+		{
+			name: "Entities",
+			html: "<p>&lt;tag&gt; &amp; &quot;quote&quot;</p><script>alert(1)</script>",
+			want: `<tag> & "quote"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, mail2.HTMLToText(tt.html))
+		})
+	}
+}
+
+// transportFunc is a Transport adapter for functions.
+type transportFunc func(ctx context.Context, from string, to []string, msg []byte) error
+
+func (f transportFunc) Deliver(ctx context.Context, from string, to []string, msg []byte) error {
+	return f(ctx, from, to, msg)
+}
+
+func TestMailer_Send(t *testing.T) {
+	var delivered bool
+	mailer := mail2.NewMailer("Vodeno <no-reply@vodeno.com>", transportFunc(
+		func(ctx context.Context, from string, to []string, msg []byte) error {
+			delivered = true
+			require.Equal(t, "no-reply@vodeno.com", from)
+			require.Equal(t, []string{"to@test.com"}, to)
+
+			header, _ := parseMessage(t, msg)
+			require.Equal(t, `"Vodeno" <no-reply@vodeno.com>`, header.Get("From"))
+			require.Equal(t, "request-1", header.Get("X-RequestID"))
+			require.Equal(t, "00-0102030405060708090a0b0c0d0e0f10-", header.Get("Traceparent")[:36])
+			return nil
+		},
+	))
+
+	traceID := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	ctx := trace.ContextWithRemoteSpanContext(requestid.NewContext(context.Background(), "request-1"),
+		trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{1}, Remote: true}))
+	err := mailer.Send(ctx, &mail2.Message{
+		To:      []string{"Client <to@test.com>"},
+		Subject: "title",
+		HTML:    "<p>content</p>",
+	})
+	require.NoError(t, err)
+	require.True(t, delivered)
+}
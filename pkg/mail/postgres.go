@@ -0,0 +1,223 @@
+package mail
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	rateTableName  = "send_rate"  // per second message counters table name.
+	leaseTableName = "send_lease" // concurrency slots table name.
+
+	// leasePollPeriod is a time between attempts to acquire concurrency slot.
+	leasePollPeriod = 50 * time.Millisecond
+	// minRatePollPeriod is a minimal time between attempts to fit in a rate window.
+	minRatePollPeriod = 10 * time.Millisecond
+	// releaseTimeout limits time of releasing or renewing slot, it's done even if message context is canceled.
+	releaseTimeout = 5 * time.Second
+	// rateRetention is a time after which counters are removed.
+	rateRetention = time.Minute
+)
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+// PostgresLimiter is postgresql implementation of Limiter.
+//
+// Rates are counted in one second windows. Windows are based on database clock
+// so they are the same for all instances of the service.
+// Concurrency slots are leases which expire after leaseTTL, in case an instance
+// crashed without releasing them. Held leases are renewed every half of leaseTTL,
+// so sends slower than leaseTTL keep their slot.
+type PostgresLimiter struct {
+	db       *sqlx.DB
+	log      *logrus.Entry
+	leaseTTL time.Duration
+}
+
+// NewPostgresLimiter creates new instance of PostgresLimiter.
+func NewPostgresLimiter(logger *logrus.Logger, db *sqlx.DB, leaseTTL time.Duration) *PostgresLimiter {
+	return &PostgresLimiter{
+		db:       db,
+		log:      logger.WithField("place", "limiter"),
+		leaseTTL: leaseTTL,
+	}
+}
+
+func (l *PostgresLimiter) Wait(ctx context.Context, key string, rate int) error {
+	for {
+		ok, err := l.take(ctx, key, rate)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		// Window is full, try again in the next one.
+		wait := time.Until(time.Now().Truncate(time.Second).Add(time.Second))
+		if wait < minRatePollPeriod {
+			wait = minRatePollPeriod
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// take increments counter of current window if it's below rate.
+func (l *PostgresLimiter) take(ctx context.Context, key string, rate int) (bool, error) {
+	q := psql.Insert(rateTableName).
+		Columns("key", "window_start", "count").
+		Values(key, sq.Expr("date_trunc('second', clock_timestamp())"), 1).
+		Suffix("ON CONFLICT (key, window_start) DO UPDATE SET count = "+rateTableName+".count + 1").
+		Suffix("WHERE "+rateTableName+".count < ? RETURNING count", rate)
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	if err := l.db.QueryRowxContext(ctx, query, args...).Scan(&count); err != nil {
+		if errors.Is(err, sql.ErrNoRows) { // conflicting row wasn't updated, window is full.
+			return false, nil
+		}
+		return false, err
+	}
+	if count == 1 { // first message in a window, good moment to remove old windows.
+		l.cleanup(ctx, key)
+	}
+	return true, nil
+}
+
+// cleanup removes old rate windows of given key.
+func (l *PostgresLimiter) cleanup(ctx context.Context, key string) {
+	q := psql.Delete(rateTableName).
+		Where(sq.Eq{"key": key}).
+		Where(sq.Lt{"window_start": time.Now().Add(-rateRetention)})
+
+	query, args, err := q.ToSql()
+	if err == nil {
+		_, err = l.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		l.log.WithContext(ctx).WithError(err).WithField("key", key).Warn("failed to remove old rate windows")
+	}
+}
+
+func (l *PostgresLimiter) Acquire(ctx context.Context, key string, concurrency int) (func(), error) {
+	holder := uuid.New().String()
+	for {
+		for slot := 0; slot < concurrency; slot++ {
+			ok, err := l.lease(ctx, key, slot, holder)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				stop, stopped := make(chan struct{}), make(chan struct{})
+				go l.renew(key, slot, holder, stop, stopped)
+				return func() {
+					close(stop)
+					<-stopped
+					l.release(key, slot, holder)
+				}, nil
+			}
+		}
+		if err := sleep(ctx, leasePollPeriod); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// lease takes slot if it's free or its lease expired.
+func (l *PostgresLimiter) lease(ctx context.Context, key string, slot int, holder string) (bool, error) {
+	q := psql.Insert(leaseTableName).
+		Columns("key", "slot", "holder", "expire_time").
+		Values(key, slot, holder, sq.Expr("clock_timestamp() + ? * interval '1 millisecond'", l.leaseTTL.Milliseconds())).
+		Suffix("ON CONFLICT (key, slot) DO UPDATE SET holder = EXCLUDED.holder, expire_time = EXCLUDED.expire_time").
+		Suffix("WHERE " + leaseTableName + ".expire_time < clock_timestamp()")
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return false, err
+	}
+	res, err := l.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// renew extends lease of slot held by holder every half of leaseTTL until stop is closed.
+func (l *PostgresLimiter) renew(key string, slot int, holder string, stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+
+	period := l.leaseTTL / 2
+	if period < leasePollPeriod {
+		period = leasePollPeriod
+	}
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			l.extend(key, slot, holder)
+		}
+	}
+}
+
+// extend sets expiration of slot held by holder to leaseTTL from now.
+func (l *PostgresLimiter) extend(key string, slot int, holder string) {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	q := psql.Update(leaseTableName).
+		Set("expire_time", sq.Expr("clock_timestamp() + ? * interval '1 millisecond'", l.leaseTTL.Milliseconds())).
+		Where(sq.Eq{"key": key, "slot": slot, "holder": holder})
+	query, args, err := q.ToSql()
+	if err == nil {
+		_, err = l.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		l.log.WithError(err).WithField("key", key).Warn("failed to renew concurrency slot, it may expire during send")
+	}
+}
+
+// release frees slot if it's still held by holder.
+func (l *PostgresLimiter) release(key string, slot int, holder string) {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	q := psql.Delete(leaseTableName).Where(sq.Eq{"key": key, "slot": slot, "holder": holder})
+	query, args, err := q.ToSql()
+	if err == nil {
+		_, err = l.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		l.log.WithError(err).WithField("key", key).Warn("failed to release concurrency slot, it will expire")
+	}
+}
+
+// sleep waits for given time or until context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
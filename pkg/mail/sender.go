@@ -0,0 +1,174 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"vodeno/pkg/config"
+	"vodeno/pkg/requestid"
+	"vodeno/pkg/tracing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Sender is an interface for sending e-mail messages.
+type Sender interface {
+	// Send builds and delivers message.
+	// It sets Message-ID header of msg when it's missing, so sent messages can be tracked.
+	// Request ID and trace context from context are added in X-RequestID and traceparent headers.
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Transport delivers already built messages.
+type Transport interface {
+	// Deliver delivers raw message from envelope sender to envelope recipients.
+	Deliver(ctx context.Context, from string, to []string, msg []byte) error
+}
+
+// Mailer implements Sender. It builds messages, signs them and hands them over to Transport.
+type Mailer struct {
+	from      string
+	transport Transport
+	signers   []Signer
+}
+
+// NewMailer creates new instance of Mailer.
+// from is used as a sender address of messages without one.
+// Signers are applied in given order.
+func NewMailer(from string, transport Transport, signers ...Signer) *Mailer {
+	return &Mailer{
+		from:      from,
+		transport: transport,
+		signers:   signers,
+	}
+}
+
+// Send builds message and delivers it using Mailer's Transport.
+func (m *Mailer) Send(ctx context.Context, msg *Message) (err error) {
+	ctx, span := tracing.Start(ctx, "mail.Mailer.Send", trace.WithSpanKind(trace.SpanKindProducer))
+	defer func() { tracing.End(span, err) }()
+
+	if msg.Header == nil {
+		msg.Header = textproto.MIMEHeader{}
+	}
+	if msg.From == "" {
+		cp := *msg
+		cp.From = m.from
+		msg = &cp
+	}
+
+	from, err := msg.Sender()
+	if err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+	if msg.Header.Get("Message-Id") == "" {
+		id, err := messageID(from.Address)
+		if err != nil {
+			return err
+		}
+		msg.Header.Set("Message-Id", id)
+	}
+	// request which triggered sending can be found by ID from the message.
+	if id, ok := requestid.FromContext(ctx); ok && msg.Header.Get(requestid.Header) == "" {
+		msg.Header.Set(requestid.Header, id)
+	}
+	// delivery continues the trace, e.g. when a bounce or webhook refers to the message.
+	if msg.Header.Get("Traceparent") == "" {
+		tracing.Inject(ctx, msg.Header)
+	}
+	to, err := msg.Recipients()
+	if err != nil {
+		return fmt.Errorf("invalid recipients: %w", err)
+	}
+	rcpts := make([]string, 0, len(to))
+	for _, addr := range to {
+		rcpts = append(rcpts, addr.Address)
+	}
+
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	for _, s := range m.signers {
+		if data, err = s.Sign(from.Address, data); err != nil {
+			return fmt.Errorf("failed to sign message: %w", err)
+		}
+	}
+	return m.transport.Deliver(ctx, from.Address, rcpts, data)
+}
+
+// SMTPTransport delivers messages to SMTP server.
+type SMTPTransport struct {
+	host string
+	addr string
+	auth smtp.Auth
+}
+
+// NewSMTPTransport creates new instance of SMTPTransport.
+func NewSMTPTransport(cfg config.SMTPConfig) *SMTPTransport {
+	t := &SMTPTransport{
+		host: cfg.Host,
+		addr: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+	}
+	if cfg.Username != "" {
+		t.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return t
+}
+
+// Deliver sends message using SMTP protocol.
+// STARTTLS is used whenever server supports it.
+func (t *SMTPTransport) Deliver(ctx context.Context, from string, to []string, msg []byte) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	c, err := smtp.NewClient(conn, t.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: t.host}); err != nil {
+			return err
+		}
+	}
+	if t.auth != nil {
+		if err := c.Auth(t.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
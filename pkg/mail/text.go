@@ -0,0 +1,127 @@
+package mail
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	spaces   = regexp.MustCompile(`[ \t\r\n\f]+`)
+	newLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText converts HTML document to its plain-text representation.
+// It's used to generate plain-text alternative of HTML messages.
+func HTMLToText(s string) string {
+	var (
+		b     strings.Builder
+		z     = html.NewTokenizer(strings.NewReader(s))
+		skip  int      // depth of elements which content is not rendered, e.g. <style>.
+		pre   int      // depth of <pre> elements.
+		links []string // stack of hrefs of currently open <a> elements.
+	)
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return cleanText(b.String())
+		case html.TextToken:
+			if skip > 0 {
+				continue
+			}
+			text := string(z.Text())
+			if pre == 0 {
+				text = spaces.ReplaceAllString(text, " ")
+				// Avoid doubled spaces and spaces at the beginning of a line.
+				if b.Len() == 0 || strings.HasSuffix(b.String(), " ") || strings.HasSuffix(b.String(), "\n") {
+					text = strings.TrimLeft(text, " ")
+				}
+			}
+			b.WriteString(text)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.DataAtom {
+			case atom.Script, atom.Style, atom.Head, atom.Title:
+				if tt == html.StartTagToken {
+					skip++
+				}
+			case atom.Pre:
+				pre++
+				b.WriteString("\n\n")
+			case atom.Br:
+				b.WriteString("\n")
+			case atom.Hr:
+				b.WriteString("\n\n----------\n\n")
+			case atom.P, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+				atom.Blockquote, atom.Table, atom.Ul, atom.Ol:
+				b.WriteString("\n\n")
+			case atom.Div, atom.Tr, atom.Section, atom.Article, atom.Header, atom.Footer:
+				b.WriteString("\n")
+			case atom.Li:
+				b.WriteString("\n* ")
+			case atom.Td, atom.Th:
+				b.WriteString(" ")
+			case atom.Img:
+				if alt := attr(tok, "alt"); alt != "" {
+					b.WriteString(alt)
+				}
+			case atom.A:
+				if tt == html.StartTagToken {
+					links = append(links, attr(tok, "href"))
+				}
+			}
+		case html.EndTagToken:
+			tok := z.Token()
+			switch tok.DataAtom {
+			case atom.Script, atom.Style, atom.Head, atom.Title:
+				if skip > 0 {
+					skip--
+				}
+			case atom.Pre:
+				if pre > 0 {
+					pre--
+				}
+				b.WriteString("\n\n")
+			case atom.P, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+				atom.Blockquote, atom.Table, atom.Ul, atom.Ol:
+				b.WriteString("\n\n")
+			case atom.Div, atom.Tr, atom.Section, atom.Article, atom.Header, atom.Footer:
+				b.WriteString("\n")
+			case atom.A:
+				if len(links) == 0 {
+					continue
+				}
+				href := links[len(links)-1]
+				links = links[:len(links)-1]
+				// Skip anchors and links which text is the URL itself.
+				if href != "" && !strings.HasPrefix(href, "#") && !strings.HasSuffix(b.String(), href) {
+					b.WriteString(" (" + href + ")")
+				}
+			}
+		}
+	}
+}
+
+// attr returns value of attribute with given key.
+func attr(tok html.Token, key string) string {
+	for _, a := range tok.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// cleanText trims spaces at the end of lines and squashes multiple empty lines.
+func cleanText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	s = strings.Join(lines, "\n")
+	return strings.TrimSpace(newLines.ReplaceAllString(s, "\n\n"))
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "vodeno"
+
+// Outcomes of sending a message to a single client.
+const (
+	OutcomeSent       = "sent"
+	OutcomeFailed     = "failed"
+	OutcomeSuppressed = "suppressed"
+)
+
+// unmatchedRoute is a route label of requests which didn't match any route,
+// it keeps number of series bounded.
+const unmatchedRoute = "unmatched"
+
+// registry holds all metrics of the service, it's exposed by Handler.
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of handled HTTP requests by route and status.",
+	}, []string{"method", "route", "status"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	grpcCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "calls_total",
+		Help:      "Number of handled gRPC calls by method and status code.",
+	}, []string{"method", "code"})
+	grpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "call_duration_seconds",
+		Help:      "Latency of gRPC calls by method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	watcherRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "watcher",
+		Name:      "runs_total",
+		Help:      "Number of runs of expired entries cleanup by result.",
+	}, []string{"result"})
+	watcherPurged = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "watcher",
+		Name:      "purged_rows_total",
+		Help:      "Number of expired entries deleted by watcher.",
+	})
+
+	messages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mail",
+		Name:      "messages_total",
+		Help:      "Number of messages processed by send by outcome (sent, failed or suppressed).",
+	}, []string{"outcome"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		grpcCalls,
+		grpcDuration,
+		watcherRuns,
+		watcherPurged,
+		messages,
+	)
+}
+
+// Handler returns http.Handler exposing metrics in Prometheus format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB adds connection pool stats of db, see sql.DB.Stats, to exposed metrics.
+func RegisterDB(db *sql.DB, name string) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveWatcherRun counts run of watcher which deleted n entries or failed with err.
+func ObserveWatcherRun(n int64, err error) {
+	if err != nil {
+		watcherRuns.WithLabelValues("failure").Inc()
+		return
+	}
+	watcherRuns.WithLabelValues("success").Inc()
+	watcherPurged.Add(float64(n))
+}
+
+// CountMessage counts message processed by send with given outcome, e.g. OutcomeSent.
+func CountMessage(outcome string) {
+	messages.WithLabelValues(outcome).Inc()
+}
+
+// ObserveGRPCCall counts gRPC call of full method, e.g. /vodeno.client.v1.ClientService/Send,
+// finished with status code, e.g. OK, and observes its duration.
+func ObserveGRPCCall(method, code string, duration time.Duration) {
+	labels := prometheus.Labels{"method": method, "code": code}
+	grpcCalls.With(labels).Inc()
+	grpcDuration.With(labels).Observe(duration.Seconds())
+}
+
+// Middleware counts requests and observes their latency by method, route pattern and status.
+// It must be used on the root router, the route is known only after request is routed.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 { // nothing was written, net/http responds with 200.
+			status = http.StatusOK
+		}
+		route := unmatchedRoute
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		labels := prometheus.Labels{"method": r.Method, "route": route, "status": strconv.Itoa(status)}
+		httpRequests.With(labels).Inc()
+		httpDuration.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
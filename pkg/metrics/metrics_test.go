@@ -0,0 +1,45 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vodeno/pkg/metrics"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(metrics.Middleware)
+	router.Route("/clients", func(r chi.Router) {
+		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {})
+		r.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/clients/1", nil),
+		httptest.NewRequest(http.MethodGet, "/clients/2", nil),
+		httptest.NewRequest(http.MethodDelete, "/clients/1", nil),
+		httptest.NewRequest(http.MethodGet, "/unknown", nil),
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	for _, line := range []string{
+		`vodeno_http_requests_total{method="GET",route="/clients/{id}",status="200"} 2`,
+		`vodeno_http_requests_total{method="DELETE",route="/clients/{id}",status="204"} 1`,
+		`vodeno_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`vodeno_http_request_duration_seconds_count{method="GET",route="/clients/{id}",status="200"} 2`,
+	} {
+		require.Contains(t, body, line)
+	}
+}
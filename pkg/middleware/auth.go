@@ -1,29 +1,123 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
+	"strings"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/problem"
+
+	"github.com/sirupsen/logrus"
 )
 
 const (
-	xTokenHeader = "X-Token" // X-Token header.
-	testToken    = "test"    // only valid value for xTokenHeader.
+	xTokenHeader        = "X-Token"       // X-Token header.
+	authorizationHeader = "Authorization" // Authorization header.
+	bearerScheme        = "bearer"        // Authorization header scheme of OAuth2 access tokens.
 )
 
-// AuthenticationMiddleware will mock authentication.
-// It expects an X-Token HTTP header.
-// It returns 401 on X-Token different that testToken.
-func AuthenticationMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		xToken := r.Header.Get(xTokenHeader)
-		if xToken != testToken {
-			authFailed(w)
-			return
+// Authenticator validates API keys or access tokens.
+type Authenticator interface {
+	// Authenticate returns Principal of given key or error if key is not valid.
+	Authenticate(ctx context.Context, key string) (*auth.Principal, error)
+}
+
+// AuthenticationMiddleware authenticates requests with API keys.
+// It expects an X-Token HTTP header with the key.
+// It returns 401 when key is missing or not valid, authenticated Principal is stored in request context.
+func AuthenticationMiddleware(log *logrus.Logger, authenticator Authenticator) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			xToken := r.Header.Get(xTokenHeader)
+			if xToken == "" {
+				authFailed(w, r)
+				return
+			}
+
+			principal, err := authenticator.Authenticate(r.Context(), xToken)
+			if err != nil {
+				log.WithContext(r.Context()).WithError(err).Warn("authentication failed")
+				authFailed(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+		})
+	}
+}
+
+// BearerAuthenticationMiddleware authenticates requests with OAuth2 access tokens, it's an alternative to
+// AuthenticationMiddleware. It expects an Authorization HTTP header with Bearer scheme.
+// It returns 401 when token is missing or not valid, authenticated Principal is stored in request context.
+func BearerAuthenticationMiddleware(log *logrus.Logger, authenticator Authenticator) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := bearerToken(r)
+			if token == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				authFailed(w, r)
+				return
+			}
+
+			principal, err := authenticator.Authenticate(r.Context(), token)
+			if err != nil {
+				log.WithContext(r.Context()).WithError(err).Warn("authentication failed")
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				authFailed(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+		})
+	}
+}
+
+// KeyOrBearerAuthenticationMiddleware authenticates requests with Authorization header of Bearer scheme by tokens
+// and other requests with X-Token header by apiKeys, see BearerAuthenticationMiddleware and AuthenticationMiddleware.
+// Only API keys are accepted when tokens is nil.
+func KeyOrBearerAuthenticationMiddleware(
+	log *logrus.Logger, apiKeys, tokens Authenticator,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		keyAuth := AuthenticationMiddleware(log, apiKeys)(next)
+		if tokens == nil {
+			return keyAuth
 		}
+		tokenAuth := BearerAuthenticationMiddleware(log, tokens)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if bearerToken(r) != "" {
+				tokenAuth.ServeHTTP(w, r)
+				return
+			}
+			keyAuth.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bearerToken returns token from Authorization header or empty string if there is none.
+func bearerToken(r *http.Request) string {
+	parts := strings.SplitN(r.Header.Get(authorizationHeader), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], bearerScheme) {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
 
-		next.ServeHTTP(w, r)
-	})
+func authFailed(w http.ResponseWriter, r *http.Request) {
+	problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.CodeUnauthorized, "missing or invalid credentials"))
 }
 
-func authFailed(w http.ResponseWriter) {
-	w.WriteHeader(http.StatusUnauthorized)
+// RequireScope is a middleware that allows only requests of Principals with given scope.
+// It must be used after AuthenticationMiddleware, it returns 403 Problem otherwise.
+func RequireScope(scope auth.Scope) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := auth.FromContext(r.Context())
+			if !ok || !principal.HasScope(scope) {
+				problem.Write(w, r, problem.New(http.StatusForbidden, problem.CodeForbidden, "missing required scope: "+string(scope)))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
@@ -0,0 +1,96 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/middleware"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// authenticatorFunc is an adapter to use function as middleware.Authenticator.
+type authenticatorFunc func(ctx context.Context, key string) (*auth.Principal, error)
+
+func (f authenticatorFunc) Authenticate(ctx context.Context, key string) (*auth.Principal, error) {
+	return f(ctx, key)
+}
+
+// acceptKey returns Authenticator which accepts only given key, Principal ID is the key.
+func acceptKey(key string) middleware.Authenticator {
+	return authenticatorFunc(func(_ context.Context, k string) (*auth.Principal, error) {
+		if k != key {
+			return nil, errors.New("invalid key")
+		}
+		return &auth.Principal{ID: k}, nil
+	})
+}
+
+func TestKeyOrBearerAuthenticationMiddleware(t *testing.T) {
+	log := logrus.New()
+	log.Out = io.Discard
+
+	for _, tt := range []struct {
+		name              string
+		tokens            middleware.Authenticator
+		header            http.Header
+		wantedStatus      int
+		wantedPrincipalID string
+	}{
+		{
+			name:              "AcceptsAPIKey",
+			tokens:            acceptKey("token"),
+			header:            http.Header{"X-Token": {"key"}},
+			wantedStatus:      http.StatusOK,
+			wantedPrincipalID: "key",
+		},
+		{
+			name:              "AcceptsBearerToken",
+			tokens:            acceptKey("token"),
+			header:            http.Header{"Authorization": {"Bearer token"}},
+			wantedStatus:      http.StatusOK,
+			wantedPrincipalID: "token",
+		},
+		{
+			name:         "ReturnsUnauthorizedOnAPIKeyAsBearerToken",
+			tokens:       acceptKey("token"),
+			header:       http.Header{"Authorization": {"Bearer key"}},
+			wantedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:         "IgnoresBearerTokenWithoutTokens",
+			header:       http.Header{"Authorization": {"Bearer token"}},
+			wantedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:         "ReturnsUnauthorizedWithoutCredentials",
+			tokens:       acceptKey("token"),
+			wantedStatus: http.StatusUnauthorized,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var principalID string
+			handler := middleware.KeyOrBearerAuthenticationMiddleware(log, acceptKey("key"), tt.tokens)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					principal, _ := auth.FromContext(r.Context())
+					principalID = principal.ID
+				}),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/clients", nil)
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantedStatus, w.Code)
+			require.Equal(t, tt.wantedPrincipalID, principalID)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"vodeno/pkg/config"
+)
+
+// CORS headers.
+const (
+	originHeader           = "Origin"
+	requestMethodHeader    = "Access-Control-Request-Method"
+	allowOriginHeader      = "Access-Control-Allow-Origin"
+	allowMethodsHeader     = "Access-Control-Allow-Methods"
+	allowHeadersHeader     = "Access-Control-Allow-Headers"
+	allowCredentialsHeader = "Access-Control-Allow-Credentials"
+	exposeHeadersHeader    = "Access-Control-Expose-Headers"
+	maxAgeHeader           = "Access-Control-Max-Age"
+)
+
+// CORSMiddleware allows browser applications from cfg.AllowedOrigins to call the API.
+// It answers preflight requests of allowed origins with 204 and adds CORS headers to other responses.
+// Requests from other origins are served without CORS headers, so browsers block them.
+// It does nothing when there are no allowed origins. Credentials are never allowed for * origin.
+func CORSMiddleware(cfg config.CORSConfig) func(next http.Handler) http.Handler {
+	anyOrigin := false
+	origins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			anyOrigin = true
+		}
+		origins[strings.ToLower(o)] = true
+	}
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+
+	return func(next http.Handler) http.Handler {
+		if len(origins) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Add("Vary", originHeader)
+			origin := r.Header.Get(originHeader)
+			if origin == "" || !(anyOrigin || origins[strings.ToLower(origin)]) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// config.CORSConfig doesn't allow credentials with * origin, they are never sent for it.
+			if anyOrigin {
+				h.Set(allowOriginHeader, "*")
+			} else {
+				h.Set(allowOriginHeader, origin)
+				if cfg.AllowCredentials {
+					h.Set(allowCredentialsHeader, "true")
+				}
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get(requestMethodHeader) != "" { // preflight.
+				h.Add("Vary", requestMethodHeader)
+				h.Set(allowMethodsHeader, methods)
+				if headers != "" {
+					h.Set(allowHeadersHeader, headers)
+				}
+				if cfg.MaxAge > 0 {
+					h.Set(maxAgeHeader, strconv.Itoa(int(cfg.MaxAge.Seconds())))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if exposed != "" {
+				h.Set(exposeHeadersHeader, exposed)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vodeno/pkg/config"
+	"vodeno/pkg/middleware"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	cfg := config.CORSConfig{
+		AllowedOrigins: []string{"https://admin.vodeno.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "X-Token"},
+		ExposedHeaders: []string{"X-RequestID"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	for _, tt := range []struct {
+		name          string
+		cfg           config.CORSConfig
+		method        string
+		headers       map[string]string
+		wantedStatus  int
+		wantedHeaders map[string]string
+	}{
+		{
+			name:         "AnswersPreflight",
+			cfg:          cfg,
+			method:       http.MethodOptions,
+			headers:      map[string]string{"Origin": "https://admin.vodeno.com", "Access-Control-Request-Method": "POST"},
+			wantedStatus: http.StatusNoContent,
+			wantedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://admin.vodeno.com",
+				"Access-Control-Allow-Methods": "GET, POST",
+				"Access-Control-Allow-Headers": "Content-Type, X-Token",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:         "AddsHeadersToResponse",
+			cfg:          cfg,
+			method:       http.MethodGet,
+			headers:      map[string]string{"Origin": "https://admin.vodeno.com"},
+			wantedStatus: http.StatusOK,
+			wantedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "https://admin.vodeno.com",
+				"Access-Control-Expose-Headers": "X-RequestID",
+			},
+		},
+		{
+			name:         "SkipsOtherOrigin",
+			cfg:          cfg,
+			method:       http.MethodOptions,
+			headers:      map[string]string{"Origin": "https://evil.com", "Access-Control-Request-Method": "POST"},
+			wantedStatus: http.StatusOK,
+			wantedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:         "AllowsAnyOrigin",
+			cfg:          config.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			method:       http.MethodGet,
+			headers:      map[string]string{"Origin": "https://crm.example.com"},
+			wantedStatus: http.StatusOK,
+			wantedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+		{
+			name:         "IsDisabledWithoutOrigins",
+			method:       http.MethodGet,
+			headers:      map[string]string{"Origin": "https://admin.vodeno.com"},
+			wantedStatus: http.StatusOK,
+			wantedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+				"Vary":                        "",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/clients", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			handler := middleware.CORSMiddleware(tt.cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantedStatus, w.Code)
+			for k, v := range tt.wantedHeaders {
+				require.Equal(t, v, w.Header().Get(k), k)
+			}
+		})
+	}
+}
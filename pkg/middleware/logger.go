@@ -3,17 +3,20 @@ package middleware
 import (
 	"net/http"
 	"time"
+	"vodeno/pkg/requestid"
 
-	"github.com/sirupsen/logrus"
-
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
 // requestIDHeader is a X-RequestID header.
-const requestIDHeader = "X-RequestID"
+const requestIDHeader = requestid.Header
 
-// LoggerMiddleware is a middleware that will log requestID taken from HTTP header (X-RequestID) and request time.
-// If there is no request ID it will generate one.
+// LoggerMiddleware is an access log middleware. It logs method, path, status, duration and size of
+// every response with request ID taken from HTTP header (X-RequestID).
+// If there is no valid request ID, see requestid.Valid, it will generate one. The ID is stored in request context, see requestid.FromContext,
+// and sent back in X-RequestID response header.
 func LoggerMiddleware(log *logrus.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return requestLogger(log)(next)
@@ -24,13 +27,39 @@ func LoggerMiddleware(log *logrus.Logger) func(next http.Handler) http.Handler {
 func requestLogger(log *logrus.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
 			requestID := r.Header.Get(requestIDHeader)
-			if requestID == "" {
+			if !requestid.Valid(requestID) {
 				requestID = uuid.New().String()
 			}
+			w.Header().Set(requestIDHeader, requestID)
 
-			log.Infof("%s: %s\n", time.Now(), requestID)
-			next.ServeHTTP(w, r)
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(requestid.NewContext(r.Context(), requestID)))
+
+			status := ww.Status()
+			if status == 0 { // nothing was written, net/http responds with 200.
+				status = http.StatusOK
+			}
+			entry := log.WithFields(logrus.Fields{
+				"request_id":  requestID,
+				"method":      r.Method,
+				"path":        r.URL.Path,
+				"status":      status,
+				"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+				"bytes":       ww.BytesWritten(),
+				"remote_addr": r.RemoteAddr,
+				"user_agent":  r.UserAgent(),
+			})
+			switch {
+			case status >= http.StatusInternalServerError:
+				entry.Error("request completed")
+			case status >= http.StatusBadRequest:
+				entry.Warn("request completed")
+			default:
+				entry.Info("request completed")
+			}
 		}
 		return http.HandlerFunc(fn)
 	}
@@ -0,0 +1,94 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vodeno/pkg/middleware"
+	"vodeno/pkg/requestid"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerMiddleware(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		requestID    string
+		replacedID   bool
+		handler      http.HandlerFunc
+		wantedStatus int
+		wantedBytes  int
+		wantedLevel  logrus.Level
+	}{
+		{
+			name:      "LogsResponse",
+			requestID: "request-1",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("hello"))
+			},
+			wantedStatus: http.StatusCreated,
+			wantedBytes:  5,
+			wantedLevel:  logrus.InfoLevel,
+		},
+		{
+			name:         "ReplacesInvalidRequestID",
+			requestID:    "request 1; <script>",
+			replacedID:   true,
+			handler:      func(w http.ResponseWriter, r *http.Request) {},
+			wantedStatus: http.StatusOK,
+			wantedLevel:  logrus.InfoLevel,
+		},
+		{
+			name:         "DefaultsTo200",
+			handler:      func(w http.ResponseWriter, r *http.Request) {},
+			wantedStatus: http.StatusOK,
+			wantedLevel:  logrus.InfoLevel,
+		},
+		{
+			name: "LogsServerErrors",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantedStatus: http.StatusInternalServerError,
+			wantedLevel:  logrus.ErrorLevel,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			log, hook := test.NewNullLogger()
+
+			req := httptest.NewRequest(http.MethodPost, "/clients", nil)
+			if tt.requestID != "" {
+				req.Header.Set("X-RequestID", tt.requestID)
+			}
+			var contextID string
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				contextID, _ = requestid.FromContext(r.Context())
+				tt.handler(w, r)
+			}
+			w := httptest.NewRecorder()
+			middleware.LoggerMiddleware(log)(http.HandlerFunc(handler)).ServeHTTP(w, req)
+
+			requestID := w.Header().Get("X-RequestID")
+			require.NotEmpty(t, requestID)
+			require.Equal(t, requestID, contextID)
+			if tt.replacedID {
+				require.NotEqual(t, tt.requestID, requestID)
+			} else if tt.requestID != "" {
+				require.Equal(t, tt.requestID, requestID)
+			}
+
+			require.Len(t, hook.Entries, 1)
+			entry := hook.LastEntry()
+			require.Equal(t, tt.wantedLevel, entry.Level)
+			require.Equal(t, requestID, entry.Data["request_id"])
+			require.Equal(t, http.MethodPost, entry.Data["method"])
+			require.Equal(t, "/clients", entry.Data["path"])
+			require.Equal(t, tt.wantedStatus, entry.Data["status"])
+			require.Equal(t, tt.wantedBytes, entry.Data["bytes"])
+			require.Contains(t, entry.Data, "duration_ms")
+		})
+	}
+}
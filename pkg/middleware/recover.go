@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+	"vodeno/pkg/problem"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// RecoverMiddleware recovers from panics of handlers. It logs the panic with stack and request ID
+// and responds with 500 Problem, unless the response was already started.
+// It must be used after LoggerMiddleware, so the request is logged with 500 status.
+func RecoverMiddleware(log *logrus.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler { // net/http aborts response silently.
+					panic(rec)
+				}
+
+				log.WithContext(r.Context()).WithFields(logrus.Fields{
+					"panic": rec,
+					"stack": string(debug.Stack()),
+				}).Error("handler panicked")
+				if ww.Status() == 0 {
+					problem.Write(ww, r, problem.Internal())
+				}
+			}()
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
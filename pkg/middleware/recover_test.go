@@ -0,0 +1,71 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vodeno/pkg/middleware"
+	"vodeno/pkg/problem"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverMiddleware(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		handler      http.HandlerFunc
+		wantedStatus int
+		wantedLogs   int
+	}{
+		{
+			name:         "PassesResponse",
+			handler:      func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) },
+			wantedStatus: http.StatusCreated,
+		},
+		{
+			name:         "Returns500OnPanic",
+			handler:      func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			wantedStatus: http.StatusInternalServerError,
+			wantedLogs:   1,
+		},
+		{
+			name: "KeepsStartedResponse",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				panic("boom")
+			},
+			wantedStatus: http.StatusOK,
+			wantedLogs:   1,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			log, hook := test.NewNullLogger()
+
+			w := httptest.NewRecorder()
+			handler := middleware.LoggerMiddleware(log)(middleware.RecoverMiddleware(log)(tt.handler))
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/clients/1", nil))
+			require.Equal(t, tt.wantedStatus, w.Code)
+
+			// access log is the last entry.
+			require.Len(t, hook.Entries, tt.wantedLogs+1)
+			require.Equal(t, tt.wantedStatus, hook.LastEntry().Data["status"])
+			if tt.wantedLogs == 0 {
+				return
+			}
+			entry := hook.Entries[0]
+			require.Equal(t, logrus.ErrorLevel, entry.Level)
+			require.Equal(t, "boom", entry.Data["panic"])
+			require.Contains(t, entry.Data["stack"], "recover_test.go")
+
+			if tt.wantedStatus == http.StatusInternalServerError {
+				var p problem.Problem
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+				require.Equal(t, problem.CodeInternal, p.Code)
+				require.Equal(t, w.Header().Get("X-RequestID"), p.RequestID)
+			}
+		})
+	}
+}
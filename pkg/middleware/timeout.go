@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"vodeno/pkg/config"
+	"vodeno/pkg/problem"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TimeoutMiddleware sets deadline of request context. Routes from cfg.Routes have their own timeouts,
+// other requests get cfg.RequestTimeout. Zero timeout means no deadline.
+// Handlers and services stop when the deadline passes, like when the client is gone, and the request
+// gets 504 Problem instead of their 500 response.
+// It returns error if a route is not "METHOD /pattern", pattern is in chi syntax, e.g. /clients/{id}.
+func TimeoutMiddleware(cfg config.HTTPConfig) (func(next http.Handler) http.Handler, error) {
+	// routes are matched by a router of their own, it knows nothing about the API routes.
+	routes := chi.NewRouter()
+	timeouts := make(map[string]time.Duration, len(cfg.Routes))
+	for _, rt := range cfg.Routes {
+		parts := strings.Fields(rt.Route)
+		if len(parts) != 2 || !strings.HasPrefix(parts[1], "/") {
+			return nil, fmt.Errorf("invalid route %q, expected METHOD /pattern", rt.Route)
+		}
+		method := strings.ToUpper(parts[0])
+		if err := handle(routes, method, parts[1]); err != nil {
+			return nil, fmt.Errorf("invalid route %q: %w", rt.Route, err)
+		}
+		timeouts[method+" "+parts[1]] = rt.Timeout
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := cfg.RequestTimeout
+			rctx := chi.NewRouteContext()
+			if routes.Match(rctx, r.Method, r.URL.Path) {
+				timeout = timeouts[r.Method+" "+rctx.RoutePattern()]
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+			tw := &timeoutWriter{ResponseWriter: w, r: r}
+			next.ServeHTTP(tw, r)
+			if !tw.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				problem.Write(w, r, problem.Timeout())
+			}
+		})
+	}, nil
+}
+
+// timeoutWriter replaces 500 responses of requests past their deadline with 504 Problem,
+// handlers report queries canceled by the deadline as internal errors.
+type timeoutWriter struct {
+	http.ResponseWriter
+	r           *http.Request
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if status == http.StatusInternalServerError && errors.Is(w.r.Context().Err(), context.DeadlineExceeded) {
+		w.timedOut = true
+		problem.Write(w.ResponseWriter, w.r, problem.Timeout())
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write discards body of replaced response.
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.timedOut {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data of streamed responses, e.g. events.
+func (w *timeoutWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// handle adds route to router, chi panics on invalid methods and patterns.
+func handle(router chi.Router, method, pattern string) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("%v", rec)
+		}
+	}()
+	router.MethodFunc(method, pattern, func(http.ResponseWriter, *http.Request) {})
+	return nil
+}
@@ -0,0 +1,120 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vodeno/pkg/config"
+	"vodeno/pkg/middleware"
+	"vodeno/pkg/problem"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	mw, err := middleware.TimeoutMiddleware(config.HTTPConfig{
+		RequestTimeout: time.Minute,
+		Routes: []config.RouteTimeoutConfig{
+			{Route: "POST /clients/send", Timeout: time.Hour},
+			{Route: "get /clients/{id}", Timeout: time.Second},
+			{Route: "GET /events"},
+		},
+	})
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name          string
+		method        string
+		path          string
+		wantedTimeout time.Duration
+	}{
+		{name: "UsesRouteTimeout", method: http.MethodPost, path: "/clients/send", wantedTimeout: time.Hour},
+		{name: "MatchesPattern", method: http.MethodGet, path: "/clients/5", wantedTimeout: time.Second},
+		{name: "MatchesMethod", method: http.MethodDelete, path: "/clients/5", wantedTimeout: time.Minute},
+		{name: "UsesDefaultTimeout", method: http.MethodPost, path: "/clients", wantedTimeout: time.Minute},
+		{name: "DisablesZeroTimeout", method: http.MethodGet, path: "/events"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				deadline time.Time
+				ok       bool
+			)
+			handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				deadline, ok = r.Context().Deadline()
+			}))
+			start := time.Now()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			if tt.wantedTimeout == 0 {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.WithinDuration(t, start.Add(tt.wantedTimeout), deadline, time.Second/10)
+		})
+	}
+}
+
+func TestTimeoutMiddleware_response(t *testing.T) {
+	mw, err := middleware.TimeoutMiddleware(config.HTTPConfig{RequestTimeout: time.Millisecond})
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name         string
+		handler      http.HandlerFunc
+		wantedStatus int
+		wantedCode   string
+	}{
+		{
+			name: "ReplacesInternalErrorPastDeadline",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				problem.Write(w, r, problem.Internal())
+			},
+			wantedStatus: http.StatusGatewayTimeout,
+			wantedCode:   problem.CodeTimeout,
+		},
+		{
+			name:         "WritesTimeoutWithoutResponse",
+			handler:      func(w http.ResponseWriter, r *http.Request) { <-r.Context().Done() },
+			wantedStatus: http.StatusGatewayTimeout,
+			wantedCode:   problem.CodeTimeout,
+		},
+		{
+			name: "KeepsOtherErrors",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				problem.Write(w, r, problem.New(http.StatusNotFound, problem.CodeNotFound, "not found"))
+			},
+			wantedStatus: http.StatusNotFound,
+			wantedCode:   problem.CodeNotFound,
+		},
+		{
+			name:         "KeepsInternalErrorBeforeDeadline",
+			handler:      func(w http.ResponseWriter, r *http.Request) { problem.Write(w, r, problem.Internal()) },
+			wantedStatus: http.StatusInternalServerError,
+			wantedCode:   problem.CodeInternal,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mw(tt.handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/clients/5", nil))
+
+			require.Equal(t, tt.wantedStatus, w.Code)
+			var p problem.Problem
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+			require.Equal(t, tt.wantedCode, p.Code)
+		})
+	}
+}
+
+func TestTimeoutMiddleware_invalidRoute(t *testing.T) {
+	for _, route := range []string{"/clients", "POST clients", "FETCH /clients"} {
+		_, err := middleware.TimeoutMiddleware(config.HTTPConfig{
+			Routes: []config.RouteTimeoutConfig{{Route: route, Timeout: time.Second}},
+		})
+		require.Error(t, err, route)
+	}
+}
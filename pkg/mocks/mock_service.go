@@ -49,6 +49,20 @@ func (mr *MockServiceMockRecorder) Add(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockService)(nil).Add), arg0, arg1)
 }
 
+// AddAttachment mocks base method.
+func (m *MockService) AddAttachment(arg0 context.Context, arg1 client.Attachment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAttachment", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAttachment indicates an expected call of AddAttachment.
+func (mr *MockServiceMockRecorder) AddAttachment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAttachment", reflect.TypeOf((*MockService)(nil).AddAttachment), arg0, arg1)
+}
+
 // Delete mocks base method.
 func (m *MockService) Delete(arg0 context.Context, arg1 int) error {
 	m.ctrl.T.Helper()
@@ -63,6 +77,22 @@ func (mr *MockServiceMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockService)(nil).Delete), arg0, arg1)
 }
 
+// DeliveryTenant mocks base method.
+func (m *MockService) DeliveryTenant(arg0 context.Context, arg1, arg2 string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeliveryTenant", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DeliveryTenant indicates an expected call of DeliveryTenant.
+func (mr *MockServiceMockRecorder) DeliveryTenant(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeliveryTenant", reflect.TypeOf((*MockService)(nil).DeliveryTenant), arg0, arg1, arg2)
+}
+
 // Get mocks base method.
 func (m *MockService) Get(arg0 context.Context, arg1 int) (*client.Entry, error) {
 	m.ctrl.T.Helper()
@@ -106,3 +136,18 @@ func (mr *MockServiceMockRecorder) Send(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockService)(nil).Send), arg0, arg1)
 }
+
+// UpdateDelivery mocks base method.
+func (m *MockService) UpdateDelivery(arg0 context.Context, arg1, arg2 string, arg3 client.DeliveryStatus, arg4 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDelivery", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateDelivery indicates an expected call of UpdateDelivery.
+func (mr *MockServiceMockRecorder) UpdateDelivery(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDelivery", reflect.TypeOf((*MockService)(nil).UpdateDelivery), arg0, arg1, arg2, arg3, arg4)
+}
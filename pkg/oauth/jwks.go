@@ -0,0 +1,258 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// minRefreshPeriod limits reloading of key set when tokens with unknown key ID are received.
+	minRefreshPeriod = 10 * time.Second
+	// fetchTimeout limits time of fetching key set from URL.
+	fetchTimeout = 10 * time.Second
+	// maxKeySetSize limits size of fetched key set.
+	maxKeySetSize = 1 << 20
+)
+
+// ErrUnknownKey is returned when key set doesn't contain key with given ID.
+var ErrUnknownKey = errors.New("unknown key")
+
+// KeySet is a JSON Web Key Set (RFC 7517) loaded from a file or URL.
+// Only RSA and P-256 EC signing keys are used, other keys are ignored.
+//
+// Keys are loaded again after refresh period, or earlier when token signed with
+// unknown key is received, so keys rotated by the issuer are picked up.
+// Keys are loaded by a single goroutine at a time and verification of tokens signed
+// with known keys doesn't wait for it.
+type KeySet struct {
+	source        string
+	refreshPeriod time.Duration
+	client        *http.Client
+	log           logrus.FieldLogger
+	group         singleflight.Group
+
+	mu       sync.RWMutex
+	keys     map[string]crypto.PublicKey
+	loadTime time.Time
+	// attemptTime is a start of the last refresh, failed ones included.
+	attemptTime time.Time
+}
+
+// NewKeySet creates new KeySet and loads keys from source, which is a path or http(s) URL.
+func NewKeySet(ctx context.Context, logger *logrus.Logger, source string, refreshPeriod time.Duration) (*KeySet, error) {
+	s := &KeySet{
+		source:        source,
+		refreshPeriod: refreshPeriod,
+		client:        &http.Client{Timeout: fetchTimeout},
+		log:           logger.WithField("place", "jwks"),
+	}
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Key returns public key with given ID. Empty ID matches the only key of the set.
+// Unknown ID makes the set refresh, at most once in minRefreshPeriod, and Key waits
+// for it until ctx is done. Known keys are returned right away, while an outdated set is refreshed in background.
+func (s *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.RLock()
+	key, ok := s.lookup(kid)
+	outdated := time.Since(s.loadTime) > s.refreshPeriod
+	canRetry := time.Since(s.attemptTime) > minRefreshPeriod
+	s.mu.RUnlock()
+
+	switch {
+	case ok && outdated && canRetry:
+		s.refresh()
+	case !ok && canRetry:
+		select {
+		case <-s.refresh():
+			s.mu.RLock()
+			key, ok = s.lookup(kid)
+			s.mu.RUnlock()
+		case <-ctx.Done():
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// lookup returns key with given ID, it must be called with mu locked.
+func (s *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// refresh starts loading keys in background, returned channel receives a result when it's done.
+// Concurrent calls share a single load. It isn't bound to context of any request,
+// so a canceled request doesn't fail the refresh of others. Failure is logged, known keys are kept.
+func (s *KeySet) refresh() <-chan singleflight.Result {
+	return s.group.DoChan("refresh", func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+		defer cancel()
+		if err := s.load(ctx); err != nil {
+			// Known keys are still valid, issuer may be temporarily unavailable.
+			s.log.WithError(err).Warn("failed to refresh key set")
+		}
+		return nil, nil
+	})
+}
+
+// load reads and parses keys from source, mu is locked only to replace them.
+func (s *KeySet) load(ctx context.Context) error {
+	s.mu.Lock()
+	s.attemptTime = time.Now()
+	s.mu.Unlock()
+
+	data, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := ParseKeySet(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.loadTime = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *KeySet) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.source, "http://") && !strings.HasPrefix(s.source, "https://") {
+		return os.ReadFile(s.source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching key set failed with status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxKeySetSize))
+}
+
+// jwk is a JSON Web Key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA parameters.
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC parameters.
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseKeySet parses JSON Web Key Set and returns public keys by their IDs.
+func ParseKeySet(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid key set: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch {
+		case k.Kty == "RSA":
+			key, err = k.rsa()
+		case k.Kty == "EC" && k.Crv == "P-256":
+			key, err = k.ecdsa()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) rsa() (*rsa.PublicKey, error) {
+	n, err := decodeInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jwk) ecdsa() (*ecdsa.PublicKey, error) {
+	x, err := decodeInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	curve := elliptic.P256()
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// decodeInt decodes base64url encoded big-endian integer.
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("missing key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
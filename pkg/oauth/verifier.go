@@ -0,0 +1,147 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/config"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ErrInvalidToken is returned when token is malformed, its signature or claims are not valid.
+var ErrInvalidToken = errors.New("invalid token")
+
+// validMethods are accepted signing algorithms, others, including HMAC and none, are rejected.
+var validMethods = []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}
+
+// Keys provides public keys used to verify token signatures.
+type Keys interface {
+	// Key returns public key with given ID.
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// Verifier authenticates requests with JWT access tokens.
+type Verifier struct {
+	keys   Keys
+	cfg    config.JWTConfig
+	scopes map[string]auth.Scope // claim values mapped to scopes.
+	parser *jwt.Parser
+	now    func() time.Time
+}
+
+// NewVerifier creates new instance of Verifier.
+func NewVerifier(keys Keys, cfg config.JWTConfig) (*Verifier, error) {
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, errors.New("issuer and audience are required")
+	}
+
+	scopes := make(map[string]auth.Scope, len(cfg.Scopes))
+	for _, m := range cfg.Scopes {
+		scope, err := auth.ParseScope(m.Scope)
+		if err != nil {
+			return nil, err
+		}
+		scopes[m.Claim] = scope
+	}
+
+	return &Verifier{
+		keys:   keys,
+		cfg:    cfg,
+		scopes: scopes,
+		// Time claims are validated by Verifier to allow leeway.
+		parser: jwt.NewParser(jwt.WithValidMethods(validMethods), jwt.WithoutClaimsValidation()),
+		now:    time.Now,
+	}, nil
+}
+
+// claims are registered claims and all claims of a token.
+type claims struct {
+	jwt.RegisteredClaims
+	raw map[string]json.RawMessage
+}
+
+func (c *claims) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &c.RegisteredClaims); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &c.raw)
+}
+
+// Authenticate verifies token and returns its Principal.
+func (v *Verifier) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	var c claims
+	_, err := v.parser.ParseWithClaims(token, &c, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if err := v.validate(&c); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var tenantID string
+	if err := json.Unmarshal(c.raw[v.cfg.TenantClaim], &tenantID); err != nil || tenantID == "" {
+		return nil, fmt.Errorf("%w: missing %s claim", ErrInvalidToken, v.cfg.TenantClaim)
+	}
+	scopes, err := v.mapScopes(c.raw[v.cfg.ScopeClaim])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return &auth.Principal{
+		ID:       "jwt:" + c.Subject,
+		Name:     c.Subject,
+		TenantID: tenantID,
+		Scopes:   scopes,
+	}, nil
+}
+
+// validate checks time claims, issuer and audience.
+func (v *Verifier) validate(c *claims) error {
+	now := v.now()
+	switch {
+	case !c.VerifyExpiresAt(now.Add(-v.cfg.Leeway), true):
+		return errors.New("token is expired or has no exp claim")
+	case !c.VerifyNotBefore(now.Add(v.cfg.Leeway), false):
+		return errors.New("token is not valid yet")
+	case !c.VerifyIssuer(v.cfg.Issuer, true):
+		return fmt.Errorf("unexpected issuer %q", c.Issuer)
+	case !c.VerifyAudience(v.cfg.Audience, true):
+		return fmt.Errorf("unexpected audience %q", c.Audience)
+	case c.Subject == "":
+		return errors.New("missing sub claim")
+	}
+	return nil
+}
+
+// mapScopes returns scopes of claim, which is a space separated string or an array.
+// Values which are neither mapped nor names of scopes are ignored.
+func (v *Verifier) mapScopes(claim json.RawMessage) ([]auth.Scope, error) {
+	if claim == nil {
+		return nil, nil
+	}
+	var values []string
+	var s string
+	if err := json.Unmarshal(claim, &s); err == nil {
+		values = strings.Fields(s)
+	} else if err := json.Unmarshal(claim, &values); err != nil {
+		return nil, errors.New("scope claim must be a string or an array of strings")
+	}
+
+	var scopes []auth.Scope
+	for _, value := range values {
+		if scope, ok := v.scopes[value]; ok {
+			scopes = append(scopes, scope)
+		} else if scope, err := auth.ParseScope(value); err == nil {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
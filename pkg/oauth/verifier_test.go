@@ -0,0 +1,267 @@
+package oauth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/config"
+	"vodeno/pkg/oauth"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	issuer   = "https://auth.example.com/"
+	audience = "vodeno"
+)
+
+// testKeys are signing keys of a local issuer.
+type testKeys struct {
+	rsa   *rsa.PrivateKey
+	ecdsa *ecdsa.PrivateKey
+	other *rsa.PrivateKey // key not published in key set.
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return testKeys{rsa: rsaKey, ecdsa: ecKey, other: otherKey}
+}
+
+// jwks returns JSON Web Key Set with public keys.
+func (k testKeys) jwks(t *testing.T) []byte {
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	coordinate := func(i *big.Int) string { return b64(i.FillBytes(make([]byte, 32))) }
+
+	data, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa", "use": "sig", "n": b64(k.rsa.N.Bytes()), "e": b64(big.NewInt(int64(k.rsa.E)).Bytes())},
+			{"kty": "EC", "kid": "ec", "crv": "P-256", "x": coordinate(k.ecdsa.X), "y": coordinate(k.ecdsa.Y)},
+			{"kty": "RSA", "kid": "enc", "use": "enc", "n": b64(k.other.N.Bytes()), "e": "AQAB"},
+			{"kty": "oct", "kid": "hmac", "k": b64([]byte("secret"))},
+		},
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	s, err := token.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func newKeySet(t *testing.T, source string) *oauth.KeySet {
+	log := logrus.New()
+	log.Out = io.Discard
+	keys, err := oauth.NewKeySet(context.Background(), log, source, time.Hour)
+	require.NoError(t, err)
+	return keys
+}
+
+func TestVerifier_Authenticate(t *testing.T) {
+	keys := newTestKeys(t)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, keys.jwks(t), 0o600))
+
+	verifier, err := oauth.NewVerifier(newKeySet(t, path), config.JWTConfig{
+		Issuer:      issuer,
+		Audience:    audience,
+		Leeway:      time.Minute,
+		ScopeClaim:  "scope",
+		TenantClaim: "tenant",
+		Scopes:      []config.ScopeMappingConfig{{Claim: "mailing.send", Scope: "mailings:send"}},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	validClaims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss":    issuer,
+			"aud":    []string{"other", audience},
+			"sub":    "crm",
+			"tenant": "sales",
+			"exp":    now.Add(time.Hour).Unix(),
+			"scope":  "clients:read mailing.send openid",
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+
+	for _, tt := range []struct {
+		name            string
+		token           string
+		wantedPrincipal *auth.Principal
+	}{
+		{
+			name:  "RS256",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, validClaims(nil)),
+			wantedPrincipal: &auth.Principal{
+				ID:       "jwt:crm",
+				Name:     "crm",
+				TenantID: "sales",
+				Scopes:   []auth.Scope{auth.ScopeClientsRead, auth.ScopeMailingsSend},
+			},
+		},
+		{
+			name:  "ES256WithScopesArray",
+			token: sign(t, jwt.SigningMethodES256, "ec", keys.ecdsa, validClaims(jwt.MapClaims{"scope": []string{"admin"}})),
+			wantedPrincipal: &auth.Principal{
+				ID:       "jwt:crm",
+				Name:     "crm",
+				TenantID: "sales",
+				Scopes:   []auth.Scope{auth.ScopeAdmin},
+			},
+		},
+		{
+			name:  "ExpiredWithinLeeway",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, validClaims(jwt.MapClaims{"exp": now.Add(-time.Second).Unix(), "scope": nil})),
+			wantedPrincipal: &auth.Principal{
+				ID:       "jwt:crm",
+				Name:     "crm",
+				TenantID: "sales",
+			},
+		},
+		{
+			name:  "ReturnsErrorOnExpiredToken",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, validClaims(jwt.MapClaims{"exp": now.Add(-time.Hour).Unix()})),
+		},
+		{
+			name:  "ReturnsErrorOnMissingExpiry",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, validClaims(jwt.MapClaims{"exp": nil})),
+		},
+		{
+			name:  "ReturnsErrorOnFutureToken",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, validClaims(jwt.MapClaims{"nbf": now.Add(time.Hour).Unix()})),
+		},
+		{
+			name:  "ReturnsErrorOnWrongIssuer",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, validClaims(jwt.MapClaims{"iss": "https://evil.example.com/"})),
+		},
+		{
+			name:  "ReturnsErrorOnWrongAudience",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, validClaims(jwt.MapClaims{"aud": "other"})),
+		},
+		{
+			name:  "ReturnsErrorOnMissingTenant",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, validClaims(jwt.MapClaims{"tenant": nil})),
+		},
+		{
+			name:  "ReturnsErrorOnUnknownKey",
+			token: sign(t, jwt.SigningMethodRS256, "missing", keys.other, validClaims(nil)),
+		},
+		{
+			name:  "ReturnsErrorOnWrongSignature",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", keys.other, validClaims(nil)),
+		},
+		{
+			name:  "ReturnsErrorOnEncryptionKey",
+			token: sign(t, jwt.SigningMethodRS256, "enc", keys.other, validClaims(nil)),
+		},
+		{
+			name:  "ReturnsErrorOnKeyOfOtherAlgorithm",
+			token: sign(t, jwt.SigningMethodES256, "rsa", keys.ecdsa, validClaims(nil)),
+		},
+		{
+			name:  "ReturnsErrorOnHMAC",
+			token: sign(t, jwt.SigningMethodHS256, "hmac", []byte("secret"), validClaims(nil)),
+		},
+		{
+			name:  "ReturnsErrorOnUnsignedToken",
+			token: sign(t, jwt.SigningMethodNone, "rsa", jwt.UnsafeAllowNoneSignatureType, validClaims(nil)),
+		},
+		{
+			name:  "ReturnsErrorOnMalformedToken",
+			token: "not.a.token",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Authenticate(context.Background(), tt.token)
+			if tt.wantedPrincipal == nil {
+				require.ErrorIs(t, err, oauth.ErrInvalidToken)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantedPrincipal, principal)
+		})
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		cfg  config.JWTConfig
+	}{
+		{
+			name: "ReturnsErrorOnMissingAudience",
+			cfg:  config.JWTConfig{Issuer: issuer},
+		},
+		{
+			name: "ReturnsErrorOnUnknownScope",
+			cfg: config.JWTConfig{
+				Issuer:   issuer,
+				Audience: audience,
+				Scopes:   []config.ScopeMappingConfig{{Claim: "all", Scope: "everything"}},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := oauth.NewVerifier(nil, tt.cfg)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestKeySet_URL(t *testing.T) {
+	keys := newTestKeys(t)
+	jwks := keys.jwks(t)
+
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		_, _ = w.Write(jwks)
+	}))
+	defer server.Close()
+
+	set := newKeySet(t, server.URL)
+
+	key, err := set.Key(context.Background(), "ec")
+	require.NoError(t, err)
+	require.Equal(t, &keys.ecdsa.PublicKey, key)
+
+	// tokens with unknown keys don't reload the key set loaded just now.
+	for i := 0; i < 3; i++ {
+		_, err = set.Key(context.Background(), "hmac")
+		require.ErrorIs(t, err, oauth.ErrUnknownKey)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
@@ -0,0 +1,57 @@
+package openapi
+
+import (
+	_ "embed" // embeds specification and docs page.
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// Spec is OpenAPI 3 specification of the API in JSON.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// docsPage renders Spec with ReDoc loaded from CDN.
+//
+//go:embed docs.html
+var docsPage []byte
+
+// Handler serves OpenAPI specification.
+type Handler struct {
+	log  *logrus.Logger
+	docs bool
+}
+
+// NewHandler returns new instance of Handler. Docs page is served only if docs is true.
+func NewHandler(log *logrus.Logger, docs bool) *Handler {
+	return &Handler{
+		log:  log,
+		docs: docs,
+	}
+}
+
+// AddRoutes adds specification routes to router. They are public.
+func (h *Handler) AddRoutes(router chi.Router) {
+	router.Get("/openapi.json", h.spec)
+	if h.docs {
+		router.Get("/docs", h.docsPage)
+	}
+}
+
+func (h *Handler) spec(w http.ResponseWriter, r *http.Request) {
+	h.write(w, r, "application/json; charset=utf-8", Spec)
+}
+
+func (h *Handler) docsPage(w http.ResponseWriter, r *http.Request) {
+	h.write(w, r, "text/html; charset=utf-8", docsPage)
+}
+
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	w.Header().Add("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		h.log.WithContext(r.Context()).WithError(err).Error("writing to ResponseWriter failed")
+	}
+}
@@ -0,0 +1,96 @@
+package openapi_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"vodeno/pkg/client"
+	"vodeno/pkg/openapi"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData(openapi.Spec)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+	return doc
+}
+
+// TestSpec_routes checks that every client route is described and every described client path is routed.
+func TestSpec_routes(t *testing.T) {
+	doc := loadSpec(t)
+
+	router := chi.NewRouter()
+	client.NewHandler(nil, nil).AddRoutes(router)
+
+	var routes []string
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		routes = append(routes, method+" "+route)
+		return nil
+	})
+	require.NoError(t, err)
+
+	var described []string
+	for path, item := range doc.Paths {
+		if !strings.HasPrefix(path, "/clients") {
+			continue
+		}
+		for method := range item.Operations() {
+			described = append(described, method+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(described)
+	require.Equal(t, routes, described)
+}
+
+// TestSpec_schemas checks that schemas have the same properties as JSON of their types.
+func TestSpec_schemas(t *testing.T) {
+	doc := loadSpec(t)
+
+	for name, v := range map[string]interface{}{
+		"Entry":       client.Entry{},
+		"Attachment":  client.Attachment{},
+		"SendRequest": client.SendRequest{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			schema, ok := doc.Components.Schemas[name]
+			require.True(t, ok, "schema %s is missing", name)
+
+			var properties []string
+			for p := range schema.Value.Properties {
+				properties = append(properties, p)
+			}
+			sort.Strings(properties)
+			require.Equal(t, jsonFields(reflect.TypeOf(v)), properties)
+		})
+	}
+}
+
+// jsonFields returns sorted names of fields of struct type t in JSON.
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.SplitN(t.Field(i).Tag.Get("json"), ",", 2)[0]
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = t.Field(i).Name
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,174 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"vodeno/pkg/requestid"
+
+	"github.com/go-playground/validator"
+)
+
+// ContentType is a media type of Problem responses.
+const ContentType = "application/problem+json"
+
+// Machine-readable codes of problems.
+const (
+	CodeInvalidJSON          = "invalid_json"
+	CodeInvalidBody          = "invalid_body"
+	CodeInvalidParameter     = "invalid_parameter"
+	CodeValidationFailed     = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeConflict             = "conflict"
+	CodeDuplicate            = "duplicate"
+	CodeSuppressed           = "suppressed"
+	CodeUnsupported          = "unsupported"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeBodyTooLarge         = "body_too_large"
+	CodeRateLimited          = "rate_limited"
+	CodeTimeout              = "timeout"
+	CodeInternal             = "internal_error"
+)
+
+// Problem is an RFC 7807 problem details error response.
+// Code and RequestID are extension members, Code identifies the problem and
+// RequestID can be used to find logs of the request.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes invalid field of request body.
+type FieldError struct {
+	// Field is a JSON path of the field, e.g. scopes[1].
+	Field string `json:"field"`
+	// Code is a failed validation rule, e.g. required or email.
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// New returns Problem with given status, code and human-readable detail.
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// InvalidBody returns Problem of request body in other format than JSON, e.g. raw message, which couldn't be parsed.
+// Bodies which couldn't be decoded as JSON get their Problem from httpjson.Problem.
+func InvalidBody(err error) *Problem {
+	return New(http.StatusBadRequest, CodeInvalidBody, err.Error())
+}
+
+// InvalidParameter returns Problem of invalid URL or query parameter.
+func InvalidParameter(name string, err error) *Problem {
+	return New(http.StatusBadRequest, CodeInvalidParameter, fmt.Sprintf("invalid %s: %s", name, err))
+}
+
+// Validation returns Problem with details of every invalid field from validator error.
+func Validation(err error) *Problem {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return New(http.StatusBadRequest, CodeValidationFailed, err.Error())
+	}
+	p := New(http.StatusBadRequest, CodeValidationFailed, "request body is not valid")
+	for _, e := range errs {
+		p.Errors = append(p.Errors, FieldError{
+			Field:   fieldPath(e.Namespace()),
+			Code:    e.Tag(),
+			Message: fieldMessage(e),
+		})
+	}
+	return p
+}
+
+// Internal returns Problem of unexpected error. Details are only logged, they may expose internals.
+func Internal() *Problem {
+	return New(http.StatusInternalServerError, CodeInternal, "")
+}
+
+// Timeout returns Problem of request which didn't finish before its deadline, e.g. a slow query was canceled.
+// It may have been partially processed.
+func Timeout() *Problem {
+	return New(http.StatusGatewayTimeout, CodeTimeout, "request timed out")
+}
+
+// Write writes Problem as response to r.
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	resp := *p
+	resp.Instance = r.URL.Path
+	resp.RequestID, _ = requestid.FromContext(r.Context())
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(resp.Status)
+	// status is already sent, client is gone if writing fails.
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// NotFound is a http.HandlerFunc responding with not found Problem, e.g. to unknown routes.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, New(http.StatusNotFound, CodeNotFound, "resource not found"))
+}
+
+// MethodNotAllowed is a http.HandlerFunc responding with method not allowed Problem.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, New(http.StatusMethodNotAllowed, CodeMethodNotAllowed, r.Method+" is not allowed"))
+}
+
+// NewValidator returns validator reporting names of fields from json tags, as they are sent by clients.
+func NewValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+	return v
+}
+
+// fieldPath strips name of the validated struct from namespace, e.g. Entry.email is email.
+func fieldPath(namespace string) string {
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// fieldMessage returns human-readable message of failed validation.
+func fieldMessage(e validator.FieldError) string {
+	switch e.Tag() {
+	case "required":
+		return "field is required"
+	case "email":
+		return "must be a valid email address"
+	case "min", "gte":
+		return "must be at least " + e.Param()
+	case "max", "lte":
+		return "must be at most " + e.Param()
+	case "oneof":
+		return "must be one of: " + e.Param()
+	default:
+		return fmt.Sprintf("failed %q validation", e.Tag())
+	}
+}
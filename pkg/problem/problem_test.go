@@ -0,0 +1,94 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vodeno/pkg/problem"
+	"vodeno/pkg/requestid"
+
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	Email   string   `json:"email" validate:"required,email"`
+	Name    string   `json:"name,omitempty" validate:"required"`
+	Targets []target `json:"targets" validate:"dive"`
+}
+
+type target struct {
+	Kind string `json:"kind" validate:"oneof=bounce complaint"`
+}
+
+func TestValidation(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		err          error
+		wantedErrors []problem.FieldError
+		wantedDetail string
+		wantedCode   string
+		wantedStatus int
+	}{
+		{
+			name: "ReportsFieldsByJSONName",
+			err: problem.NewValidator().Struct(request{
+				Email:   "bademail",
+				Targets: []target{{Kind: "bounce"}, {Kind: "other"}},
+			}),
+			wantedErrors: []problem.FieldError{
+				{Field: "email", Code: "email", Message: "must be a valid email address"},
+				{Field: "name", Code: "required", Message: "field is required"},
+				{Field: "targets[1].kind", Code: "oneof", Message: "must be one of: bounce complaint"},
+			},
+			wantedDetail: "request body is not valid",
+			wantedCode:   problem.CodeValidationFailed,
+			wantedStatus: http.StatusBadRequest,
+		},
+		{
+			name:         "KeepsOtherErrorsInDetail",
+			err:          errors.New("invalid scope"),
+			wantedDetail: "invalid scope",
+			wantedCode:   problem.CodeValidationFailed,
+			wantedStatus: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := problem.Validation(tt.err)
+			require.Equal(t, tt.wantedStatus, p.Status)
+			require.Equal(t, tt.wantedCode, p.Code)
+			require.Equal(t, tt.wantedDetail, p.Detail)
+			require.Equal(t, tt.wantedErrors, p.Errors)
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/clients/5", nil)
+	req = req.WithContext(requestid.NewContext(req.Context(), "request-1"))
+	rec := httptest.NewRecorder()
+
+	problem.Write(rec, req, problem.New(http.StatusNotFound, problem.CodeNotFound, "entry not found"))
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	require.Equal(t, map[string]interface{}{
+		"type":       "about:blank",
+		"title":      "Not Found",
+		"status":     float64(http.StatusNotFound),
+		"detail":     "entry not found",
+		"instance":   "/clients/5",
+		"code":       problem.CodeNotFound,
+		"request_id": "request-1",
+	}, body)
+}
+
+func TestInvalidBody(t *testing.T) {
+	p := problem.InvalidBody(errors.New("malformed MIME header"))
+	require.Equal(t, http.StatusBadRequest, p.Status)
+	require.Equal(t, problem.CodeInvalidBody, p.Code)
+	require.Equal(t, "malformed MIME header", p.Detail)
+}
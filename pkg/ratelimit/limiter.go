@@ -0,0 +1,177 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/config"
+	"vodeno/pkg/problem"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Rate limit response headers.
+const (
+	LimitHeader      = "RateLimit-Limit"     // maximum burst of requests.
+	RemainingHeader  = "RateLimit-Remaining" // number of requests allowed right now.
+	ResetHeader      = "RateLimit-Reset"     // seconds until the bucket is full again.
+	RetryAfterHeader = "Retry-After"         // seconds until rejected request is allowed.
+)
+
+// Kinds of Store.
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
+
+// Limit is a token bucket. Every request takes a token, tokens are added at Rate up to Burst.
+type Limit struct {
+	// Rate is a number of tokens added per second.
+	Rate float64
+	// Burst is a capacity of the bucket, buckets start full.
+	Burst int
+}
+
+// refill returns number of tokens in the bucket after elapsed time.
+func (l Limit) refill(tokens float64, elapsed time.Duration) float64 {
+	return math.Min(float64(l.Burst), tokens+elapsed.Seconds()*l.Rate)
+}
+
+// Store keeps token buckets.
+type Store interface {
+	// Take takes a token from the bucket of key if there is one.
+	// It returns number of tokens left and false if the bucket was empty.
+	Take(ctx context.Context, key string, limit Limit) (tokens float64, ok bool, err error)
+}
+
+// NewStore returns Store of given kind, db is used by StorePostgres only.
+func NewStore(logger *logrus.Logger, db *sqlx.DB, kind string) (Store, error) {
+	switch kind {
+	case StoreMemory:
+		return NewMemoryStore(), nil
+	case StorePostgres:
+		return NewPostgresStore(logger, db), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q, expected memory or postgres", kind)
+	}
+}
+
+// Limiter limits rate of requests of every API key, or client IP of unauthenticated requests.
+type Limiter struct {
+	log      *logrus.Entry
+	store    Store
+	fallback Limit
+	groups   map[string]Limit
+}
+
+// NewLimiter creates new instance of Limiter with limits of route groups from cfg.
+func NewLimiter(logger *logrus.Logger, store Store, cfg config.RequestLimitConfig) *Limiter {
+	groups := make(map[string]Limit, len(cfg.Groups))
+	for _, g := range cfg.Groups {
+		groups[g.Group] = newLimit(g)
+	}
+	return &Limiter{
+		log:      logger.WithField("place", "ratelimit"),
+		store:    store,
+		fallback: newLimit(cfg.Default),
+		groups:   groups,
+	}
+}
+
+func newLimit(cfg config.RouteLimitConfig) Limit {
+	limit := Limit{Rate: cfg.Rate, Burst: cfg.Burst}
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return limit
+}
+
+// Middleware limits requests to routes of given group, every caller has its own bucket in every group.
+// It must be used after authentication, requests without Principal are limited by client IP.
+// It sets RateLimit-* headers and responds with 429 Problem and Retry-After header when the limit is exceeded.
+// Requests are allowed when Store fails.
+func (l *Limiter) Middleware(group string) func(next http.Handler) http.Handler {
+	limit := l.limit(group)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limit.Rate <= 0 { // no limit.
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := group + ":" + caller(r)
+			tokens, ok, err := l.store.Take(r.Context(), key, limit)
+			if err != nil {
+				l.log.WithContext(r.Context()).WithError(err).WithField("key", key).Error("failed to check rate limit")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set(LimitHeader, strconv.Itoa(limit.Burst))
+			h.Set(RemainingHeader, strconv.Itoa(int(math.Max(0, math.Floor(tokens)))))
+			h.Set(ResetHeader, strconv.Itoa(seconds((float64(limit.Burst)-tokens)/limit.Rate)))
+			if !ok {
+				retryAfter := seconds((1 - tokens) / limit.Rate)
+				h.Set(RetryAfterHeader, strconv.Itoa(retryAfter))
+				problem.Write(w, r, problem.New(http.StatusTooManyRequests, problem.CodeRateLimited,
+					fmt.Sprintf("rate limit exceeded, retry after %d seconds", retryAfter)))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Allow takes a token of caller, e.g. Principal ID, from its bucket in given group, like Middleware does
+// for APIs other than HTTP. It returns false and seconds until a call is allowed when the limit is exceeded.
+// Calls are allowed when Store fails.
+func (l *Limiter) Allow(ctx context.Context, group, caller string) (retryAfter int, ok bool) {
+	limit := l.limit(group)
+	if limit.Rate <= 0 { // no limit.
+		return 0, true
+	}
+
+	key := group + ":" + caller
+	tokens, ok, err := l.store.Take(ctx, key, limit)
+	if err != nil {
+		l.log.WithContext(ctx).WithError(err).WithField("key", key).Error("failed to check rate limit")
+		return 0, true
+	}
+	if !ok {
+		return seconds((1 - tokens) / limit.Rate), false
+	}
+	return 0, true
+}
+
+// limit returns Limit of group or the default one.
+func (l *Limiter) limit(group string) Limit {
+	if limit, ok := l.groups[group]; ok {
+		return limit
+	}
+	return l.fallback
+}
+
+// caller returns Principal ID of the request or its client IP.
+func caller(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		return principal.ID
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// seconds rounds up s to whole seconds.
+func seconds(s float64) int {
+	return int(math.Ceil(s))
+}
@@ -0,0 +1,179 @@
+package ratelimit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/config"
+	"vodeno/pkg/problem"
+	"vodeno/pkg/ratelimit"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStore is a Store which always fails.
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (float64, bool, error) {
+	return 0, false, errors.New("db is down")
+}
+
+// request is a request of Principal with given ID from given address, anonymous if principalID is empty.
+type request struct {
+	principalID string
+	remoteAddr  string
+}
+
+func TestLimiter_Middleware(t *testing.T) {
+	log := logrus.New()
+	log.Out = io.Discard
+
+	cfg := config.RequestLimitConfig{
+		Default: config.RouteLimitConfig{Rate: 0.01, Burst: 2},
+		Groups: []config.RouteLimitConfig{
+			{Group: "clients", Rate: 0.01, Burst: 1},
+			{Group: "unlimited"},
+		},
+	}
+	key1 := request{principalID: "apikey:1", remoteAddr: "10.0.0.1:1234"}
+
+	for _, tt := range []struct {
+		name           string
+		store          ratelimit.Store
+		group          string
+		requests       []request
+		wantedStatuses []int
+	}{
+		{
+			name:           "AllowsBurst",
+			group:          "suppressions",
+			requests:       []request{key1, key1, key1},
+			wantedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:           "UsesGroupLimit",
+			group:          "clients",
+			requests:       []request{key1, key1},
+			wantedStatuses: []int{http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:  "LimitsKeysSeparately",
+			group: "clients",
+			requests: []request{
+				key1,
+				{principalID: "apikey:2", remoteAddr: "10.0.0.1:1234"},
+				key1,
+			},
+			wantedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:  "LimitsAnonymousRequestsByIP",
+			group: "clients",
+			requests: []request{
+				{remoteAddr: "10.0.0.1:1234"},
+				{remoteAddr: "10.0.0.1:5678"},
+				{remoteAddr: "10.0.0.2:1234"},
+			},
+			wantedStatuses: []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+		},
+		{
+			name:           "DoesNotLimitZeroRate",
+			group:          "unlimited",
+			requests:       []request{key1, key1, key1},
+			wantedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		{
+			name:           "AllowsRequestsOnStoreError",
+			store:          failingStore{},
+			group:          "clients",
+			requests:       []request{key1, key1},
+			wantedStatuses: []int{http.StatusOK, http.StatusOK},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.store
+			if store == nil {
+				store = ratelimit.NewMemoryStore()
+			}
+			handler := ratelimit.NewLimiter(log, store, cfg).Middleware(tt.group)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			)
+
+			var statuses []int
+			for _, rq := range tt.requests {
+				req := httptest.NewRequest(http.MethodPost, "/clients", nil)
+				req.RemoteAddr = rq.remoteAddr
+				if rq.principalID != "" {
+					req = req.WithContext(auth.NewContext(req.Context(), &auth.Principal{ID: rq.principalID}))
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+				statuses = append(statuses, w.Code)
+			}
+			require.Equal(t, tt.wantedStatuses, statuses)
+		})
+	}
+}
+
+func TestLimiter_Middleware_headers(t *testing.T) {
+	log := logrus.New()
+	log.Out = io.Discard
+
+	limiter := ratelimit.NewLimiter(log, ratelimit.NewMemoryStore(), config.RequestLimitConfig{
+		Default: config.RouteLimitConfig{Rate: 0.1, Burst: 2},
+	})
+	handler := limiter.Middleware("clients")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/clients", nil))
+		return w
+	}
+
+	w := serve()
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "2", w.Header().Get(ratelimit.LimitHeader))
+	require.Equal(t, "1", w.Header().Get(ratelimit.RemainingHeader))
+	require.Equal(t, "10", w.Header().Get(ratelimit.ResetHeader))
+	require.Empty(t, w.Header().Get(ratelimit.RetryAfterHeader))
+
+	serve()
+	w = serve()
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "0", w.Header().Get(ratelimit.RemainingHeader))
+	require.Equal(t, "20", w.Header().Get(ratelimit.ResetHeader))
+	require.Equal(t, "10", w.Header().Get(ratelimit.RetryAfterHeader))
+	require.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+	var p problem.Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+	require.Equal(t, problem.CodeRateLimited, p.Code)
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Rate: 100, Burst: 1}
+	ctx := context.Background()
+
+	_, ok, err := store.Take(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	tokens, ok, err := store.Take(ctx, "key", limit)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Less(t, tokens, 1.0)
+
+	// a token is added every 10ms.
+	time.Sleep(20 * time.Millisecond)
+	_, ok, err = store.Take(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
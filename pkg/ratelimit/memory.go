@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepPeriod is a time between removals of full buckets from memory.
+const sweepPeriod = time.Minute
+
+// bucket is a state of token bucket.
+type bucket struct {
+	tokens     float64
+	updateTime time.Time
+	// fullTime is a time after which the bucket is full, it can be removed then.
+	fullTime time.Time
+}
+
+// MemoryStore is in-process implementation of Store, every instance of the service limits requests separately.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	sweepTime time.Time
+}
+
+// NewMemoryStore creates new instance of MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*bucket),
+		sweepTime: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.sweepTime) > sweepPeriod {
+		s.sweep(now)
+	}
+
+	b, found := s.buckets[key]
+	if !found {
+		b = &bucket{tokens: float64(limit.Burst), updateTime: now}
+		s.buckets[key] = b
+	}
+	b.tokens = limit.refill(b.tokens, now.Sub(b.updateTime))
+	b.updateTime = now
+
+	ok := b.tokens >= 1
+	if ok {
+		b.tokens--
+	}
+	b.fullTime = now.Add(time.Duration((float64(limit.Burst) - b.tokens) / limit.Rate * float64(time.Second)))
+	return b.tokens, ok, nil
+}
+
+// sweep removes buckets which are full, they are created again when needed.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !b.fullTime.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+	s.sweepTime = now
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	tableName = "rate_limit_bucket"
+
+	// refillExpr is a number of tokens in a stored bucket after refill, its arguments are burst and rate.
+	refillExpr = "LEAST(?, " + tableName + ".tokens + EXTRACT(EPOCH FROM clock_timestamp() - " + tableName + ".update_time) * ?)"
+
+	// cleanupPeriod is a time between removals of idle buckets.
+	cleanupPeriod = time.Minute
+	// bucketRetention is a time after which idle buckets are removed, it's longer than refill of any sane limit.
+	bucketRetention = time.Hour
+)
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+// PostgresStore is postgresql implementation of Store, buckets are shared by all instances of the service.
+// Buckets are refilled with database clock, so it's the same for all instances.
+type PostgresStore struct {
+	db  *sqlx.DB
+	log *logrus.Entry
+
+	mu          sync.Mutex
+	cleanupTime time.Time
+}
+
+// NewPostgresStore creates new instance of PostgresStore.
+func NewPostgresStore(logger *logrus.Logger, db *sqlx.DB) *PostgresStore {
+	return &PostgresStore{
+		db:          db,
+		log:         logger.WithField("place", "ratelimit"),
+		cleanupTime: time.Now(),
+	}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (float64, bool, error) {
+	s.cleanup(ctx)
+
+	// bucket is updated only if it has a token after refill.
+	q := psql.Insert(tableName).
+		Columns("key", "tokens", "update_time").
+		Values(key, limit.Burst-1, sq.Expr("clock_timestamp()")).
+		Suffix("ON CONFLICT (key) DO UPDATE SET tokens = "+refillExpr+" - 1, update_time = clock_timestamp()",
+			limit.Burst, limit.Rate).
+		Suffix("WHERE "+refillExpr+" >= 1 RETURNING tokens", limit.Burst, limit.Rate)
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return 0, false, err
+	}
+
+	var tokens float64
+	err = s.db.QueryRowxContext(ctx, query, args...).Scan(&tokens)
+	if err == nil {
+		return tokens, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+
+	// conflicting row wasn't updated, the bucket is empty.
+	query, args, err = psql.Select().
+		Column(sq.Expr(refillExpr, limit.Burst, limit.Rate)).
+		From(tableName).
+		Where(sq.Eq{"key": key}).
+		ToSql()
+	if err != nil {
+		return 0, false, err
+	}
+	if err := s.db.QueryRowxContext(ctx, query, args...).Scan(&tokens); err != nil {
+		return 0, false, err
+	}
+	return tokens, false, nil
+}
+
+// cleanup removes buckets idle for bucketRetention, at most once per cleanupPeriod.
+func (s *PostgresStore) cleanup(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.cleanupTime) < cleanupPeriod {
+		s.mu.Unlock()
+		return
+	}
+	s.cleanupTime = time.Now()
+	s.mu.Unlock()
+
+	q := psql.Delete(tableName).
+		Where(sq.Expr("update_time < clock_timestamp() - ? * interval '1 second'", bucketRetention.Seconds()))
+
+	query, args, err := q.ToSql()
+	if err == nil {
+		_, err = s.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).Warn("failed to remove idle rate limit buckets")
+	}
+}
@@ -0,0 +1,60 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Header is a HTTP and mail header with request ID.
+const Header = "X-RequestID"
+
+// maxLength is the maximum length of valid request ID.
+const maxLength = 128
+
+// Valid returns true if id received from a client can be used as request ID: it's not empty and has at most
+// 128 letters, digits, dots, underscores or hyphens. Request IDs are copied to logs, mail headers and audit trail.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDKey is a context key of request ID.
+type requestIDKey struct{}
+
+// NewContext returns context with given request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext returns request ID stored in context.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Hook adds request_id field to log entries with context, e.g. created with logger.WithContext(ctx).
+type Hook struct{}
+
+func (Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (Hook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if id, ok := FromContext(entry.Context); ok {
+		entry.Data["request_id"] = id
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package requestid_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"vodeno/pkg/requestid"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHook(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	log.AddHook(requestid.Hook{})
+
+	ctx := requestid.NewContext(context.Background(), "request-1")
+	log.WithContext(ctx).WithField("handler", "add").Info("with context")
+	require.Equal(t, "request-1", hook.LastEntry().Data["request_id"])
+	require.Equal(t, "add", hook.LastEntry().Data["handler"])
+
+	log.Info("without context")
+	require.NotContains(t, hook.LastEntry().Data, "request_id")
+}
+
+func TestValid(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		id     string
+		wanted bool
+	}{
+		{name: "AcceptsUUID", id: "6f1c0c52-1f5e-4c38-9a0e-2b0a8f3f9d1e", wanted: true},
+		{name: "AcceptsDotsAndUnderscores", id: "svc.send_1", wanted: true},
+		{name: "AcceptsMaxLength", id: strings.Repeat("a", 128), wanted: true},
+		{name: "RejectsEmpty", id: ""},
+		{name: "RejectsTooLong", id: strings.Repeat("a", 129)},
+		{name: "RejectsHeaderInjection", id: "id\r\nBcc: victim@test.com"},
+		{name: "RejectsNonASCII", id: "żółw"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.wanted, requestid.Valid(tt.id))
+		})
+	}
+}
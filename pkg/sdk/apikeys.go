@@ -0,0 +1,72 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIKey is an API key without its plaintext.
+type APIKey struct {
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	TenantID     string     `json:"tenant_id"`
+	Prefix       string     `json:"prefix"`
+	Scopes       []string   `json:"scopes"`
+	CreateTime   time.Time  `json:"create_time"`
+	ExpireTime   *time.Time `json:"expire_time,omitempty"`
+	LastUsedTime *time.Time `json:"last_used_time,omitempty"`
+	RevokeTime   *time.Time `json:"revoke_time,omitempty"`
+}
+
+// IssueAPIKeyRequest is a request to issue API key for the caller's tenant.
+type IssueAPIKeyRequest struct {
+	Name string `json:"name"`
+	// Scopes are e.g. clients:read, clients:write, mailings:send, bounces:write or admin.
+	Scopes     []string   `json:"scopes"`
+	ExpireTime *time.Time `json:"expire_time,omitempty"`
+}
+
+// IssuedAPIKey is a newly issued API key, its plaintext Key is never shown again.
+type IssuedAPIKey struct {
+	Key    string  `json:"key"`
+	APIKey *APIKey `json:"api_key"`
+}
+
+type listAPIKeysResponse struct {
+	Keys []APIKey `json:"keys"`
+}
+
+// IssueAPIKey issues API key. It requires admin scope.
+func (c *Client) IssueAPIKey(ctx context.Context, req IssueAPIKeyRequest) (*IssuedAPIKey, error) {
+	var resp IssuedAPIKey
+	if _, err := c.do(ctx, http.MethodPost, "/apikeys", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListAPIKeys lists API keys. It requires admin scope.
+func (c *Client) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var resp listAPIKeysResponse
+	if _, err := c.do(ctx, http.MethodGet, "/apikeys", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Keys, nil
+}
+
+// RotateAPIKey revokes API key and issues a new one with the same name and scopes. It requires admin scope.
+func (c *Client) RotateAPIKey(ctx context.Context, id int) (*IssuedAPIKey, error) {
+	var resp IssuedAPIKey
+	if _, err := c.do(ctx, http.MethodPost, "/apikeys/"+strconv.Itoa(id)+"/rotate", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RevokeAPIKey revokes API key. It requires admin scope.
+func (c *Client) RevokeAPIKey(ctx context.Context, id int) error {
+	_, err := c.do(ctx, http.MethodDelete, "/apikeys/"+strconv.Itoa(id), nil, nil, nil)
+	return err
+}
@@ -0,0 +1,78 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// AuditRecord is an audit trail record of a mutating request.
+type AuditRecord struct {
+	ID         int       `json:"id"`
+	Actor      string    `json:"actor"`
+	RequestID  string    `json:"request_id"`
+	Action     string    `json:"action"`
+	Targets    []string  `json:"targets"`
+	Status     int       `json:"status"`
+	Result     string    `json:"result"`
+	InsertTime time.Time `json:"insert_time"`
+}
+
+// AuditFilter filters listed audit records, empty fields are not used.
+type AuditFilter struct {
+	Actor string
+	// Action is a method and route pattern, e.g. DELETE /clients/{id}.
+	Action string
+	// Target is an affected object, e.g. id:5.
+	Target  string
+	From    time.Time
+	To      time.Time
+	Limit   int
+	AfterID int
+}
+
+// AuditPage is a page of listed audit records.
+type AuditPage struct {
+	Records []AuditRecord
+	// NextAfterID is AfterID of the next page, it's zero if there are no more records.
+	NextAfterID int
+}
+
+type listAuditResponse struct {
+	Records []AuditRecord `json:"records"`
+}
+
+// ListAudit returns a page of audit records of the caller's tenant. It requires admin scope.
+func (c *Client) ListAudit(ctx context.Context, f AuditFilter) (*AuditPage, error) {
+	query := url.Values{}
+	for name, value := range map[string]string{"actor": f.Actor, "action": f.Action, "target": f.Target} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if !f.From.IsZero() {
+		query.Set("from", f.From.Format(time.RFC3339))
+	}
+	if !f.To.IsZero() {
+		query.Set("to", f.To.Format(time.RFC3339))
+	}
+	if f.Limit > 0 {
+		query.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if f.AfterID > 0 {
+		query.Set("after_id", strconv.Itoa(f.AfterID))
+	}
+
+	var resp listAuditResponse
+	httpResp, err := c.do(ctx, http.MethodGet, "/audit", query, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	page := &AuditPage{Records: resp.Records}
+	if httpResp.StatusCode == http.StatusOK && len(resp.Records) > 0 {
+		page.NextAfterID, _ = strconv.Atoi(httpResp.Header.Get("after_id"))
+	}
+	return page, nil
+}
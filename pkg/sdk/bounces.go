@@ -0,0 +1,42 @@
+package sdk
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// Types of bounce events.
+const (
+	EventBounce    = "bounce"
+	EventComplaint = "complaint"
+)
+
+// BounceEvent is a bounce or complaint about a sent message.
+type BounceEvent struct {
+	Type  string `json:"type"`
+	Email string `json:"email"`
+	// MessageID is a Message-ID of the original message, it's optional.
+	MessageID string `json:"message_id,omitempty"`
+	// BounceType is hard or soft, it's set for bounces only.
+	BounceType string `json:"bounce_type,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Diagnostic string `json:"diagnostic,omitempty"`
+}
+
+// IngestBounces records bounce and complaint events. It requires bounces:write scope.
+func (c *Client) IngestBounces(ctx context.Context, events []BounceEvent) error {
+	_, err := c.do(ctx, http.MethodPost, "/bounces", nil, events, nil)
+	return err
+}
+
+// IngestBounceReport records raw DSN or ARF report, e.g. read from a bounce mailbox.
+// It requires bounces:write scope.
+func (c *Client) IngestBounceReport(ctx context.Context, report io.Reader) error {
+	body, err := io.ReadAll(report)
+	if err != nil {
+		return err
+	}
+	_, err = c.doRaw(ctx, http.MethodPost, "/bounces", nil, "message/rfc822", body, nil)
+	return err
+}
@@ -0,0 +1,195 @@
+// Package sdk is a Go client of the service's HTTP API.
+//
+// It depends only on the standard library and uuid, so it can be used by other services
+// without pulling dependencies of the server.
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	xTokenHeader    = "X-Token"     // X-Token header with API key.
+	requestIDHeader = "X-RequestID" // X-RequestID header.
+
+	// defaultMaxRetries is the default number of retries of failed requests.
+	defaultMaxRetries = 3
+	// defaultBackoff is the default delay before the first retry, it doubles with every next one.
+	defaultBackoff = 100 * time.Millisecond
+)
+
+// Client is a client of the API. It's safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	apiKey     string
+	token      string
+	maxRetries int
+	backoff    time.Duration
+}
+
+// Option configures Client.
+type Option func(c *Client)
+
+// WithAPIKey authenticates requests with API key sent in X-Token header.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithBearerToken authenticates requests with OAuth2 access token, it's used instead of API key.
+func WithBearerToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithHTTPClient sets http.Client used to send requests, http.DefaultClient is used by default.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithRetries sets maximum number of retries and delay before the first one, delay doubles with every retry.
+// Zero maxRetries disables retries.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// New returns Client of the API at baseURL, e.g. https://vodeno.example.com.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// requestIDKey is a context key of request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns context with request ID sent in X-RequestID header of requests made with it,
+// e.g. to continue request ID of the caller. A new ID is generated for every call otherwise.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// do sends request with JSON body in and decodes JSON response to out, if they are not nil.
+// It returns *Error if server responded with an error.
+//
+// Failed requests are retried with the same request ID: GET and DELETE requests on network
+// errors and every 5xx status, other requests only on 429, 502, 503 and 504 of a proxy, when they were not processed.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) (*http.Response, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return nil, err
+		}
+	}
+	return c.doRaw(ctx, method, path, query, "application/json", body, out)
+}
+
+// doRaw is like do, but it sends body as is with given content type.
+func (c *Client) doRaw(
+	ctx context.Context, method, path string, query url.Values, contentType string, body []byte, out interface{},
+) (*http.Response, error) {
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	if !ok {
+		requestID = uuid.New().String()
+	}
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		var reader io.Reader = http.NoBody
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set(requestIDHeader, requestID)
+		switch {
+		case c.token != "":
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		case c.apiKey != "":
+			req.Header.Set(xTokenHeader, c.apiKey)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err == nil {
+			err = c.handleResponse(resp, requestID, out)
+		}
+		if err == nil || attempt >= c.maxRetries || !retryable(method, err) {
+			return resp, err
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return resp, err
+		case <-t.C:
+		}
+		backoff *= 2
+	}
+}
+
+// handleResponse decodes successful response to out or returns *Error.
+func (c *Client) handleResponse(resp *http.Response, requestID string, out interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return newError(resp, requestID)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// retryable returns true if request failed with err may be sent again.
+func retryable(method string, err error) bool {
+	e, ok := err.(*Error)
+	idempotent := method == http.MethodGet || method == http.MethodDelete
+	if !ok { // network error, request could be processed.
+		return idempotent
+	}
+	switch {
+	case e.Code == CodeTimeout: // the API timed out, request could be partially processed.
+		return idempotent
+	case e.Status == http.StatusBadGateway, e.Status == http.StatusServiceUnavailable, e.Status == http.StatusGatewayTimeout,
+		e.Status == http.StatusTooManyRequests:
+		return true
+	case e.Status >= http.StatusInternalServerError:
+		return idempotent
+	default:
+		return false
+	}
+}
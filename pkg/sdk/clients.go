@@ -0,0 +1,175 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// defaultPageSize is the number of entries fetched at once by ClientIterator.
+const defaultPageSize = 100
+
+// Entry is a client entry of a mailing.
+type Entry struct {
+	ID        int    `json:"id,omitempty"`
+	Email     string `json:"email"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	MailingID int    `json:"mailing_id"`
+	// InsertTime is required.
+	InsertTime time.Time `json:"insert_time"`
+}
+
+// Attachment is a file attached to every message of a mailing.
+type Attachment struct {
+	ID          int    `json:"id,omitempty"`
+	MailingID   int    `json:"mailing_id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	// ContentID makes attachment inline, content references it with cid: URL, e.g. <img src="cid:logo">.
+	ContentID string `json:"content_id,omitempty"`
+	Data      []byte `json:"data"`
+	// InsertTime is set by the server when it's zero.
+	InsertTime time.Time `json:"insert_time"`
+}
+
+// ListOptions paginates listed entries. Zero Limit means the server's default.
+type ListOptions struct {
+	Limit   int
+	AfterID int
+}
+
+// ClientPage is a page of listed entries.
+type ClientPage struct {
+	Clients []Entry
+	// NextAfterID is AfterID of the next page, it's zero if there are no more entries.
+	NextAfterID int
+}
+
+type listClientsResponse struct {
+	Clients []Entry `json:"clients"`
+}
+
+type sendRequest struct {
+	MailingID int `json:"mailing_id"`
+}
+
+// AddClient adds client entry. It requires clients:write scope.
+func (c *Client) AddClient(ctx context.Context, entry Entry) error {
+	_, err := c.do(ctx, http.MethodPost, "/clients", nil, entry, nil)
+	return err
+}
+
+// GetClient returns entry with given ID, *Error with CodeNotFound if there is none. It requires clients:read scope.
+func (c *Client) GetClient(ctx context.Context, id int) (*Entry, error) {
+	var entry Entry
+	if _, err := c.do(ctx, http.MethodGet, "/clients/"+strconv.Itoa(id), nil, nil, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// DeleteClient deletes entry with given ID. It requires clients:write scope.
+func (c *Client) DeleteClient(ctx context.Context, id int) error {
+	_, err := c.do(ctx, http.MethodDelete, "/clients/"+strconv.Itoa(id), nil, nil, nil)
+	return err
+}
+
+// ListClients returns a page of entries. See Clients to iterate over all of them.
+// It requires clients:read scope.
+func (c *Client) ListClients(ctx context.Context, opts ListOptions) (*ClientPage, error) {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.AfterID > 0 {
+		query.Set("after_id", strconv.Itoa(opts.AfterID))
+	}
+
+	var resp listClientsResponse
+	httpResp, err := c.do(ctx, http.MethodGet, "/clients", query, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	page := &ClientPage{Clients: resp.Clients}
+	if httpResp.StatusCode == http.StatusOK && len(resp.Clients) > 0 {
+		page.NextAfterID, _ = strconv.Atoi(httpResp.Header.Get("after_id"))
+	}
+	return page, nil
+}
+
+// Send sends emails to every entry of the mailing. It requires mailings:send scope.
+func (c *Client) Send(ctx context.Context, mailingID int) error {
+	_, err := c.do(ctx, http.MethodPost, "/clients/send", nil, sendRequest{MailingID: mailingID}, nil)
+	return err
+}
+
+// AddAttachment adds attachment to every message of the mailing. It requires clients:write scope.
+func (c *Client) AddAttachment(ctx context.Context, attachment Attachment) error {
+	_, err := c.do(ctx, http.MethodPost, "/clients/attachments", nil, attachment, nil)
+	return err
+}
+
+// Clients returns iterator over all entries, starting after opts.AfterID.
+// opts.Limit is a size of fetched pages.
+//
+//	it := c.Clients(ListOptions{})
+//	for it.Next(ctx) {
+//		entry := it.Entry()
+//	}
+//	if err := it.Err(); err != nil {
+func (c *Client) Clients(opts ListOptions) *ClientIterator {
+	if opts.Limit <= 0 {
+		opts.Limit = defaultPageSize
+	}
+	return &ClientIterator{client: c, opts: opts}
+}
+
+// ClientIterator iterates over entries, it fetches next page when the current one is consumed.
+type ClientIterator struct {
+	client *Client
+	opts   ListOptions
+	page   []Entry
+	entry  Entry
+	done   bool
+	err    error
+}
+
+// Next advances to the next entry. It returns false when there are no more entries or fetching failed.
+func (it *ClientIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if len(it.page) == 0 {
+		if it.done {
+			return false
+		}
+		page, err := it.client.ListClients(ctx, it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if page.NextAfterID == 0 || len(page.Clients) < it.opts.Limit { // the last page.
+			it.done = true
+		}
+		it.opts.AfterID = page.NextAfterID
+		it.page = page.Clients
+		if len(it.page) == 0 {
+			return false
+		}
+	}
+	it.entry, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Entry returns the current entry.
+func (it *ClientIterator) Entry() Entry {
+	return it.entry
+}
+
+// Err returns error which stopped iteration, if any.
+func (it *ClientIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,82 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Error codes returned by the API.
+const (
+	CodeInvalidJSON          = "invalid_json"
+	CodeInvalidBody          = "invalid_body"
+	CodeInvalidParameter     = "invalid_parameter"
+	CodeValidationFailed     = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeConflict             = "conflict"
+	CodeDuplicate            = "duplicate"
+	CodeSuppressed           = "suppressed"
+	CodeUnsupported          = "unsupported"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeBodyTooLarge         = "body_too_large"
+	CodeRateLimited          = "rate_limited"
+	CodeTimeout              = "timeout"
+	CodeInternal             = "internal_error"
+)
+
+// Error is an error response of the API, it's decoded from RFC 7807 problem details.
+type Error struct {
+	Status    int          `json:"status"`
+	Code      string       `json:"code"`
+	Title     string       `json:"title"`
+	Detail    string       `json:"detail"`
+	RequestID string       `json:"request_id"`
+	Errors    []FieldError `json:"errors"`
+}
+
+// FieldError describes invalid field of request body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("vodeno: %d %s", e.Status, e.Code)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	for _, f := range e.Errors {
+		msg += fmt.Sprintf("; %s: %s", f.Field, f.Message)
+	}
+	return msg
+}
+
+// HasCode returns true if err is *Error with given code, e.g. CodeNotFound.
+func HasCode(err error, code string) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+// newError returns Error of failed response. Responses which are not problem details,
+// e.g. of a proxy, get code from their status.
+func newError(resp *http.Response, requestID string) *Error {
+	e := &Error{}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err := json.Unmarshal(body, e); err != nil || e.Code == "" {
+		e = &Error{Title: http.StatusText(resp.StatusCode), Detail: string(body)}
+		if resp.StatusCode >= http.StatusInternalServerError {
+			e.Code = CodeInternal
+		}
+	}
+	e.Status = resp.StatusCode
+	if e.RequestID == "" {
+		e.RequestID = requestID
+	}
+	return e
+}
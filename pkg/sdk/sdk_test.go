@@ -0,0 +1,279 @@
+package sdk_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/client"
+	"vodeno/pkg/middleware"
+	"vodeno/pkg/mocks"
+	"vodeno/pkg/requestid"
+	"vodeno/pkg/sdk"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "key"
+
+// authenticator accepts testKey only, its Principal has admin scope.
+type authenticator struct{}
+
+func (authenticator) Authenticate(_ context.Context, key string) (*auth.Principal, error) {
+	if key != testKey {
+		return nil, errors.New("invalid key")
+	}
+	return &auth.Principal{ID: "apikey:1", Name: "test", Scopes: []auth.Scope{auth.ScopeAdmin}}, nil
+}
+
+// failFirst responds with given status to the first n requests.
+func failFirst(n int32, status int) func(next http.Handler) http.Handler {
+	var count int32
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&count, 1) <= n {
+				w.WriteHeader(status)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// newTestClient returns sdk.Client of the real client routes backed by service mock.
+func newTestClient(t *testing.T, mock *mocks.MockService, opts ...sdk.Option) *sdk.Client {
+	return newTestClientWith(t, mock, nil, opts...)
+}
+
+// newTestClientWith is like newTestClient, but mw is used in front of the routes if it's not nil.
+func newTestClientWith(
+	t *testing.T, mock *mocks.MockService, mw func(next http.Handler) http.Handler, opts ...sdk.Option,
+) *sdk.Client {
+	log := logrus.New()
+	log.Out = io.Discard
+
+	router := chi.NewRouter()
+	if mw != nil {
+		router.Use(mw)
+	}
+	router.Use(middleware.LoggerMiddleware(log))
+	router.Use(middleware.AuthenticationMiddleware(log, authenticator{}))
+	client.NewHandler(log, mock).AddRoutes(router)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	opts = append([]sdk.Option{sdk.WithAPIKey(testKey), sdk.WithRetries(3, time.Millisecond)}, opts...)
+	c, err := sdk.New(server.URL, opts...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestClient_authentication(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		opts       []sdk.Option
+		prep       func(mock *mocks.MockService)
+		wantedCode string
+	}{
+		{
+			name: "SendsAPIKey",
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Get(gomock.Any(), 1).Return(&client.Entry{ID: 1}, nil)
+			},
+		},
+		{
+			name:       "ReturnsUnauthorizedOnInvalidKey",
+			opts:       []sdk.Option{sdk.WithAPIKey("invalid")},
+			wantedCode: sdk.CodeUnauthorized,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mocks.NewMockService(gomock.NewController(t))
+			if tt.prep != nil {
+				tt.prep(mock)
+			}
+
+			_, err := newTestClient(t, mock, tt.opts...).GetClient(context.Background(), 1)
+			if tt.wantedCode == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.True(t, sdk.HasCode(err, tt.wantedCode), err)
+		})
+	}
+}
+
+func TestClient_requestID(t *testing.T) {
+	mock := mocks.NewMockService(gomock.NewController(t))
+	mock.EXPECT().Delete(gomock.Any(), 7).DoAndReturn(func(ctx context.Context, id int) error {
+		requestID, _ := requestid.FromContext(ctx)
+		require.Equal(t, "request-1", requestID)
+		return fmt.Errorf("entry %d: %w", id, client.ErrNotFound)
+	})
+
+	ctx := sdk.WithRequestID(context.Background(), "request-1")
+	err := newTestClient(t, mock).DeleteClient(ctx, 7)
+
+	var sdkErr *sdk.Error
+	require.True(t, errors.As(err, &sdkErr), err)
+	require.Equal(t, http.StatusNotFound, sdkErr.Status)
+	require.Equal(t, sdk.CodeNotFound, sdkErr.Code)
+	require.Equal(t, "request-1", sdkErr.RequestID)
+}
+
+func TestClient_errors(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		entry        sdk.Entry
+		prep         func(mock *mocks.MockService)
+		wantedCode   string
+		wantedFields []string
+	}{
+		{
+			name:  "ReturnsConflictOnDuplicate",
+			entry: sdk.Entry{Email: "email@test.com", Title: "title", Content: "content", MailingID: 1, InsertTime: time.Now()},
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Add(gomock.Any(), gomock.Any()).Return(client.ErrDuplicate)
+			},
+			wantedCode: sdk.CodeDuplicate,
+		},
+		{
+			name:         "ReturnsFieldErrorsOnInvalidEntry",
+			entry:        sdk.Entry{Email: "invalid", Title: "title", Content: "content", MailingID: 1, InsertTime: time.Now()},
+			wantedCode:   sdk.CodeValidationFailed,
+			wantedFields: []string{"email"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mocks.NewMockService(gomock.NewController(t))
+			if tt.prep != nil {
+				tt.prep(mock)
+			}
+
+			err := newTestClient(t, mock).AddClient(context.Background(), tt.entry)
+			require.True(t, sdk.HasCode(err, tt.wantedCode), err)
+
+			var sdkErr *sdk.Error
+			require.True(t, errors.As(err, &sdkErr))
+			var fields []string
+			for _, f := range sdkErr.Errors {
+				fields = append(fields, f.Field)
+			}
+			require.Equal(t, tt.wantedFields, fields)
+		})
+	}
+}
+
+func TestClient_retries(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		mw          func(next http.Handler) http.Handler
+		prep        func(mock *mocks.MockService)
+		call        func(ctx context.Context, c *sdk.Client) error
+		wantedError bool
+	}{
+		{
+			name: "RetriesGetOnInternalError",
+			prep: func(mock *mocks.MockService) {
+				gomock.InOrder(
+					mock.EXPECT().Get(gomock.Any(), 1).Return(nil, errors.New("db is down")).Times(2),
+					mock.EXPECT().Get(gomock.Any(), 1).Return(&client.Entry{ID: 1}, nil),
+				)
+			},
+			call: func(ctx context.Context, c *sdk.Client) error {
+				_, err := c.GetClient(ctx, 1)
+				return err
+			},
+		},
+		{
+			name: "DoesNotRetryPostOnInternalError",
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Send(gomock.Any(), 1).Return(errors.New("db is down"))
+			},
+			call: func(ctx context.Context, c *sdk.Client) error {
+				return c.Send(ctx, 1)
+			},
+			wantedError: true,
+		},
+		{
+			name: "DoesNotRetryPostOnTimeout",
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Send(gomock.Any(), 1).Return(fmt.Errorf("send: %w", context.DeadlineExceeded))
+			},
+			call: func(ctx context.Context, c *sdk.Client) error {
+				return c.Send(ctx, 1)
+			},
+			wantedError: true,
+		},
+		{
+			name: "RetriesPostOnServiceUnavailable",
+			mw:   failFirst(2, http.StatusServiceUnavailable),
+			prep: func(mock *mocks.MockService) {
+				mock.EXPECT().Send(gomock.Any(), 1)
+			},
+			call: func(ctx context.Context, c *sdk.Client) error {
+				return c.Send(ctx, 1)
+			},
+		},
+		{
+			name: "GivesUpAfterMaxRetries",
+			mw:   failFirst(4, http.StatusServiceUnavailable),
+			call: func(ctx context.Context, c *sdk.Client) error {
+				return c.Send(ctx, 1)
+			},
+			wantedError: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mocks.NewMockService(gomock.NewController(t))
+			if tt.prep != nil {
+				tt.prep(mock)
+			}
+
+			err := tt.call(context.Background(), newTestClientWith(t, mock, tt.mw))
+			if tt.wantedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestClientIterator(t *testing.T) {
+	entries := []client.Entry{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}}
+
+	mock := mocks.NewMockService(gomock.NewController(t))
+	mock.EXPECT().List(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, cursor client.Cursor) ([]client.Entry, error) {
+			start := 0
+			if cursor.AfterID != nil {
+				start = *cursor.AfterID
+			}
+			end := start + cursor.Limit
+			if end > len(entries) {
+				end = len(entries)
+			}
+			return entries[start:end], nil
+		},
+	).Times(3)
+
+	ctx := context.Background()
+	it := newTestClient(t, mock).Clients(sdk.ListOptions{Limit: 2})
+	var ids []int
+	for it.Next(ctx) {
+		ids = append(ids, it.Entry().ID)
+	}
+	require.NoError(t, it.Err())
+	require.Equal(t, []int{1, 2, 3, 4, 5}, ids)
+}
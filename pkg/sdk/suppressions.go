@@ -0,0 +1,36 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Reasons of address suppression.
+const (
+	ReasonUnsubscribed = "unsubscribed"
+	ReasonHardBounce   = "hard_bounce"
+	ReasonComplaint    = "complaint"
+	ReasonManual       = "manual"
+)
+
+// Suppression is an address which must not be mailed.
+type Suppression struct {
+	Email  string `json:"email"`
+	Reason string `json:"reason"`
+	// InsertTime is set by the server when it's zero.
+	InsertTime time.Time `json:"insert_time"`
+}
+
+// AddSuppression adds address to suppression list. It requires clients:write scope.
+func (c *Client) AddSuppression(ctx context.Context, s Suppression) error {
+	_, err := c.do(ctx, http.MethodPost, "/suppressions", nil, s, nil)
+	return err
+}
+
+// DeleteSuppression removes address from suppression list. It requires clients:write scope.
+func (c *Client) DeleteSuppression(ctx context.Context, email string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/suppressions/"+url.PathEscape(email), nil, nil, nil)
+	return err
+}
@@ -0,0 +1,152 @@
+package suppression
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"vodeno/pkg/audit"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/httpjson"
+	"vodeno/pkg/middleware"
+	"vodeno/pkg/problem"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator"
+	"github.com/sirupsen/logrus"
+)
+
+// unsubscribePage is shown when unsubscribe link is opened in a browser.
+// Unsubscribing on GET is avoided because links are often visited by mail scanners.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+{{if .Done}}<p>You have been unsubscribed.</p>{{else}}<form method="post" action="?token={{.Token}}">
+<p>Do you want to stop receiving our emails?</p>
+<button type="submit">Unsubscribe</button>
+</form>{{end}}
+</body>
+</html>
+`))
+
+// Handler is a http handler for suppression list.
+type Handler struct {
+	service   Service
+	validator *validator.Validate
+	log       *logrus.Logger
+}
+
+// NewHandler returns new instance of Handler.
+func NewHandler(log *logrus.Logger, svc Service) *Handler {
+	return &Handler{
+		service:   svc,
+		validator: problem.NewValidator(),
+		log:       log,
+	}
+}
+
+// AddRoutes adds suppression list management routes to router. They require clients:write scope.
+func (h *Handler) AddRoutes(router chi.Router) {
+	router.Route("/suppressions", func(r chi.Router) {
+		r.Use(middleware.RequireScope(auth.ScopeClientsWrite))
+		r.Post("/", h.add)
+		r.Delete("/{email}", h.delete)
+	})
+}
+
+// AddPublicRoutes adds unsubscribe page route to router. It must not require authentication.
+func (h *Handler) AddPublicRoutes(router chi.Router) {
+	router.Get("/unsubscribe", h.unsubscribePage)
+}
+
+// AddOneClickRoutes adds one-click unsubscribe route (RFC 8058) to router, the page posts to it too.
+// It must not require authentication. Mailbox providers post to it from few shared IPs,
+// so it shouldn't be rate limited by IP, the signed token guards it.
+func (h *Handler) AddOneClickRoutes(router chi.Router) {
+	router.Post("/unsubscribe", h.unsubscribe)
+}
+
+// add gets Suppression from http request and calls Service for creation.
+func (h *Handler) add(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "addSuppression")
+	var req Suppression
+
+	if err := httpjson.Decode(r, &req); err != nil {
+		logger.WithError(err).Error("failed to decode request")
+		problem.Write(w, r, httpjson.Problem(err))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logger.WithError(err).Error("request is not valid")
+		problem.Write(w, r, problem.Validation(err))
+		return
+	}
+
+	audit.AddTarget(ctx, "email", req.Email)
+	if err := h.service.Add(ctx, req); err != nil {
+		logger.WithError(err).Error("failed to add suppression")
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// delete removes address from suppression list.
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "deleteSuppression")
+
+	email := chi.URLParam(r, "email")
+	if email == "" {
+		logger.Error("email is empty")
+		problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidParameter, "email is required"))
+		return
+	}
+
+	if err := h.service.Delete(ctx, email); err != nil {
+		logger.WithError(err).Error("failed to delete suppression")
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unsubscribePage renders unsubscribe confirmation form.
+func (h *Handler) unsubscribePage(w http.ResponseWriter, r *http.Request) {
+	h.writePage(w, http.StatusOK, r.URL.Query().Get("token"), false)
+}
+
+// unsubscribe records unsubscribe. It handles both confirmation form
+// and RFC 8058 one-click requests sent by mailbox providers.
+func (h *Handler) unsubscribe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "unsubscribe")
+
+	token := r.URL.Query().Get("token")
+	if err := h.service.Unsubscribe(ctx, token); err != nil {
+		logger.WithError(err).Error("failed to unsubscribe")
+		if errors.Is(err, ErrInvalidToken) {
+			problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidParameter, err.Error()))
+			return
+		}
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+	h.writePage(w, http.StatusOK, "", true)
+}
+
+func (h *Handler) writePage(w http.ResponseWriter, status int, token string, done bool) {
+	w.Header().Add("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := unsubscribePage.Execute(w, struct {
+		Token string
+		Done  bool
+	}{token, done}); err != nil {
+		h.log.WithError(err).Error("failed to render unsubscribe page")
+	}
+}
@@ -0,0 +1,21 @@
+package suppression
+
+import "time"
+
+// Reason is a reason of address suppression.
+type Reason string
+
+const (
+	ReasonUnsubscribed Reason = "unsubscribed" // recipient unsubscribed.
+	ReasonHardBounce   Reason = "hard_bounce"  // address doesn't exist.
+	ReasonComplaint    Reason = "complaint"    // recipient marked message as spam.
+	ReasonManual       Reason = "manual"       // added by API user.
+)
+
+// Suppression represents an address which must not be mailed.
+type Suppression struct {
+	TenantID   string    `json:"-" db:"tenant_id"` // set by Service from tenant in context.
+	Email      string    `json:"email" db:"email" validate:"required,email"`
+	Reason     Reason    `json:"reason" db:"reason" validate:"required,oneof=unsubscribed hard_bounce complaint manual"`
+	InsertTime time.Time `json:"insert_time" db:"insert_time"`
+}
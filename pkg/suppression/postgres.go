@@ -0,0 +1,81 @@
+package suppression
+
+import (
+	"context"
+	"strings"
+	"vodeno/pkg/db"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const tableName = "suppression" // suppression table name.
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+// repo is postgresql implementation of Repository.
+//
+// Queries filter by tenant_id and run in a transaction as db.TenantRole, so row-level
+// security hides rows of other tenants even if a condition is missing.
+type repo struct {
+	db  *sqlx.DB
+	log *logrus.Entry
+}
+
+// NewRepo creates new instance of repo.
+func NewRepo(logger *logrus.Logger, db *sqlx.DB) *repo {
+	return &repo{
+		db:  db,
+		log: logger.WithField("place", "suppression_repo"),
+	}
+}
+
+func (r repo) Insert(ctx context.Context, s Suppression) error {
+	return db.InTenant(ctx, r.db, s.TenantID, func(tx *sqlx.Tx) error {
+		q := psql.Insert(tableName).
+			Columns("tenant_id", "email", "reason", "insert_time").
+			Values(s.TenantID, strings.ToLower(s.Email), s.Reason, s.InsertTime).
+			Suffix("ON CONFLICT (tenant_id, email) DO NOTHING")
+
+		query, args, err := q.ToSql()
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, query, args...)
+		return err
+	})
+}
+
+func (r repo) Delete(ctx context.Context, tenantID, email string) error {
+	return db.InTenant(ctx, r.db, tenantID, func(tx *sqlx.Tx) error {
+		q := psql.Delete(tableName).Where(sq.Eq{"tenant_id": tenantID, "email": strings.ToLower(email)})
+		query, args, err := q.ToSql()
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, query, args...)
+		return err
+	})
+}
+
+func (r repo) GetFilter(ctx context.Context, tenantID string, emails []string) ([]Suppression, error) {
+	lower := make([]string, 0, len(emails))
+	for _, e := range emails {
+		lower = append(lower, strings.ToLower(e))
+	}
+
+	var suppressions []Suppression
+	err := db.InTenant(ctx, r.db, tenantID, func(tx *sqlx.Tx) error {
+		q := psql.Select("*").From(tableName).Where(sq.Eq{"tenant_id": tenantID, "email": lower})
+		query, args, err := q.ToSql()
+		if err != nil {
+			return err
+		}
+		return tx.SelectContext(ctx, &suppressions, query, args...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return suppressions, nil
+}
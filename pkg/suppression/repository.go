@@ -0,0 +1,13 @@
+package suppression
+
+import "context"
+
+// Repository is a repository interface. Suppressions are kept per tenant.
+type Repository interface {
+	// Insert inserts Suppression to storage. Already suppressed addresses are left untouched.
+	Insert(ctx context.Context, s Suppression) error
+	// Delete deletes Suppression of given tenant and email from storage.
+	Delete(ctx context.Context, tenantID, email string) error
+	// GetFilter gets Suppressions of given tenant and emails.
+	GetFilter(ctx context.Context, tenantID string, emails []string) ([]Suppression, error)
+}
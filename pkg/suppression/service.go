@@ -0,0 +1,155 @@
+package suppression
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/config"
+)
+
+// ErrInvalidToken is returned when unsubscribe token is malformed or its signature doesn't match.
+var ErrInvalidToken = errors.New("invalid unsubscribe token")
+
+// legacyTenantID is a tenant of tokens issued before suppressions were kept per tenant.
+const legacyTenantID = "default"
+
+// Service is a service interface. Every tenant has its own suppression list,
+// methods use tenant from context and return auth.ErrNoTenant if there is none.
+type Service interface {
+	// Add adds address to suppression list.
+	Add(ctx context.Context, s Suppression) error
+	// Delete removes address from suppression list.
+	Delete(ctx context.Context, email string) error
+	// Suppressed returns set of given emails which are suppressed.
+	// Returned emails are lower-cased.
+	Suppressed(ctx context.Context, emails ...string) (map[string]bool, error)
+	// UnsubscribeURL returns signed one-click unsubscribe link for given tenant and email.
+	UnsubscribeURL(tenantID, email string) string
+	// Unsubscribe verifies token from unsubscribe link and suppresses its address in its tenant.
+	// It doesn't need tenant in context.
+	Unsubscribe(ctx context.Context, token string) error
+}
+
+// service implements Service interface.
+type service struct {
+	repository Repository
+	baseURL    string
+	secret     []byte
+}
+
+// NewService returns new Service.
+func NewService(repository Repository, cfg config.UnsubscribeConfig) Service {
+	return service{
+		repository: repository,
+		baseURL:    strings.TrimSuffix(cfg.BaseURL, "/"),
+		secret:     []byte(cfg.Secret),
+	}
+}
+
+func (s service) Add(ctx context.Context, suppression Suppression) error {
+	tenantID, ok := auth.TenantFromContext(ctx)
+	if !ok {
+		return auth.ErrNoTenant
+	}
+	suppression.TenantID = tenantID
+	return s.add(ctx, suppression)
+}
+
+// add inserts suppression of its tenant.
+func (s service) add(ctx context.Context, suppression Suppression) error {
+	if suppression.InsertTime.IsZero() {
+		suppression.InsertTime = time.Now()
+	}
+	return s.repository.Insert(ctx, suppression)
+}
+
+func (s service) Delete(ctx context.Context, email string) error {
+	tenantID, ok := auth.TenantFromContext(ctx)
+	if !ok {
+		return auth.ErrNoTenant
+	}
+	return s.repository.Delete(ctx, tenantID, email)
+}
+
+func (s service) Suppressed(ctx context.Context, emails ...string) (map[string]bool, error) {
+	tenantID, ok := auth.TenantFromContext(ctx)
+	if !ok {
+		return nil, auth.ErrNoTenant
+	}
+	suppressed := make(map[string]bool)
+	if len(emails) == 0 {
+		return suppressed, nil
+	}
+	suppressions, err := s.repository.GetFilter(ctx, tenantID, emails)
+	if err != nil {
+		return nil, err
+	}
+	for _, sup := range suppressions {
+		suppressed[sup.Email] = true
+	}
+	return suppressed, nil
+}
+
+func (s service) UnsubscribeURL(tenantID, email string) string {
+	return s.baseURL + "/unsubscribe?" + url.Values{"token": {s.token(tenantID, email)}}.Encode()
+}
+
+func (s service) Unsubscribe(ctx context.Context, token string) error {
+	tenantID, email, err := s.verify(token)
+	if err != nil {
+		return err
+	}
+	return s.add(ctx, Suppression{TenantID: tenantID, Email: email, Reason: ReasonUnsubscribed})
+}
+
+// token returns token in format: base64(tenant).base64(email).base64(HMAC-SHA256(tenant NUL email)).
+func (s service) token(tenantID, email string) string {
+	email = strings.ToLower(email)
+	return base64.RawURLEncoding.EncodeToString([]byte(tenantID)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(email)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.sign([]byte(tenantID+"\x00"+email)))
+}
+
+// verify checks token signature and returns tenant and email it was issued for.
+// Tokens without tenant, base64(email).base64(HMAC-SHA256(email)), are still in sent
+// messages, they belong to legacyTenantID.
+func (s service) verify(token string) (string, string, error) {
+	parts := strings.Split(token, ".")
+	decoded := make([][]byte, 0, len(parts))
+	for _, p := range parts {
+		b, err := base64.RawURLEncoding.DecodeString(p)
+		if err != nil {
+			return "", "", ErrInvalidToken
+		}
+		decoded = append(decoded, b)
+	}
+
+	var tenantID, email string
+	var payload, sig []byte
+	switch len(decoded) {
+	case 2:
+		tenantID, email = legacyTenantID, string(decoded[0])
+		payload, sig = decoded[0], decoded[1]
+	case 3:
+		tenantID, email = string(decoded[0]), string(decoded[1])
+		payload, sig = []byte(tenantID+"\x00"+email), decoded[2]
+	default:
+		return "", "", ErrInvalidToken
+	}
+	if tenantID == "" || !hmac.Equal(sig, s.sign(payload)) {
+		return "", "", ErrInvalidToken
+	}
+	return tenantID, email, nil
+}
+
+func (s service) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
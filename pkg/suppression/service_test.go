@@ -0,0 +1,135 @@
+package suppression_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/config"
+	"vodeno/pkg/suppression"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRepo is in-memory implementation of suppression.Repository, keyed by tenant and email.
+type memoryRepo map[string]suppression.Suppression
+
+func (m memoryRepo) Insert(_ context.Context, s suppression.Suppression) error {
+	s.Email = strings.ToLower(s.Email)
+	if _, ok := m[s.TenantID+"/"+s.Email]; !ok {
+		m[s.TenantID+"/"+s.Email] = s
+	}
+	return nil
+}
+
+func (m memoryRepo) Delete(_ context.Context, tenantID, email string) error {
+	delete(m, tenantID+"/"+strings.ToLower(email))
+	return nil
+}
+
+func (m memoryRepo) GetFilter(_ context.Context, tenantID string, emails []string) ([]suppression.Suppression, error) {
+	var res []suppression.Suppression
+	for _, e := range emails {
+		if s, ok := m[tenantID+"/"+strings.ToLower(e)]; ok {
+			res = append(res, s)
+		}
+	}
+	return res, nil
+}
+
+func tenantContext(tenantID string) context.Context {
+	return auth.NewContext(context.Background(), &auth.Principal{ID: "apikey:1", TenantID: tenantID})
+}
+
+func TestService_Unsubscribe(t *testing.T) {
+	repo := memoryRepo{}
+	svc := suppression.NewService(repo, config.UnsubscribeConfig{BaseURL: "https://vodeno.com/", Secret: "secret"})
+
+	link, err := url.Parse(svc.UnsubscribeURL("t1", "Client@Test.com"))
+	require.NoError(t, err)
+	require.Equal(t, "https://vodeno.com/unsubscribe", link.Scheme+"://"+link.Host+link.Path)
+	token := link.Query().Get("token")
+
+	for _, tt := range []struct {
+		name  string
+		token string
+	}{
+		{name: "ReturnsErrorOnEmptyToken", token: ""},
+		{name: "ReturnsErrorOnMalformedToken", token: "abc"},
+		{name: "ReturnsErrorOnTamperedToken", token: "x" + token},
+		{name: "ReturnsErrorOnForeignSecret", token: suppressionToken(t, "other", "t1", "client@test.com")},
+		{name: "ReturnsErrorOnChangedTenant", token: b64("t2") + token[strings.Index(token, "."):]},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, svc.Unsubscribe(context.Background(), tt.token), suppression.ErrInvalidToken)
+		})
+	}
+
+	ctx := tenantContext("t1")
+	suppressed, err := svc.Suppressed(ctx, "client@test.com")
+	require.NoError(t, err)
+	require.Empty(t, suppressed)
+
+	require.NoError(t, svc.Unsubscribe(context.Background(), token))
+
+	suppressed, err = svc.Suppressed(ctx, "CLIENT@test.com", "other@test.com")
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"client@test.com": true}, suppressed)
+	require.Equal(t, suppression.ReasonUnsubscribed, repo["t1/client@test.com"].Reason)
+	require.False(t, repo["t1/client@test.com"].InsertTime.IsZero())
+
+	// other tenants still mail the address.
+	suppressed, err = svc.Suppressed(tenantContext("t2"), "client@test.com")
+	require.NoError(t, err)
+	require.Empty(t, suppressed)
+}
+
+func TestService_UnsubscribeLegacyToken(t *testing.T) {
+	repo := memoryRepo{}
+	svc := suppression.NewService(repo, config.UnsubscribeConfig{Secret: "secret"})
+
+	// tokens of messages sent before tenants were added have no tenant part.
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("client@test.com"))
+	token := b64("client@test.com") + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	require.NoError(t, svc.Unsubscribe(context.Background(), token))
+	require.Contains(t, repo, "default/client@test.com")
+}
+
+func TestService_tenants(t *testing.T) {
+	svc := suppression.NewService(memoryRepo{}, config.UnsubscribeConfig{Secret: "secret"})
+
+	require.NoError(t, svc.Add(tenantContext("t1"), suppression.Suppression{
+		Email: "client@test.com", Reason: suppression.ReasonHardBounce,
+	}))
+	// deleting in other tenant leaves the suppression.
+	require.NoError(t, svc.Delete(tenantContext("t2"), "client@test.com"))
+
+	suppressed, err := svc.Suppressed(tenantContext("t1"), "client@test.com")
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"client@test.com": true}, suppressed)
+
+	require.ErrorIs(t, svc.Add(context.Background(), suppression.Suppression{Email: "client@test.com"}), auth.ErrNoTenant)
+	require.ErrorIs(t, svc.Delete(context.Background(), "client@test.com"), auth.ErrNoTenant)
+	_, err = svc.Suppressed(context.Background(), "client@test.com")
+	require.ErrorIs(t, err, auth.ErrNoTenant)
+}
+
+func b64(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// suppressionToken returns unsubscribe token signed with given secret.
+func suppressionToken(t *testing.T, secret, tenantID, email string) string {
+	t.Helper()
+
+	svc := suppression.NewService(memoryRepo{}, config.UnsubscribeConfig{Secret: secret})
+	link, err := url.Parse(svc.UnsubscribeURL(tenantID, email))
+	require.NoError(t, err)
+	return link.Query().Get("token")
+}
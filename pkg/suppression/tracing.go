@@ -0,0 +1,53 @@
+package suppression
+
+import (
+	"context"
+	"vodeno/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedService is a Service which records span of every call.
+type tracedService struct {
+	next Service
+}
+
+// NewTracedService wraps svc to record span of every call.
+func NewTracedService(svc Service) Service {
+	return tracedService{next: svc}
+}
+
+func (s tracedService) Add(ctx context.Context, suppression Suppression) error {
+	ctx, span := tracing.Start(ctx, "suppression.Service.Add")
+	err := s.next.Add(ctx, suppression)
+	tracing.End(span, err)
+	return err
+}
+
+func (s tracedService) Delete(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "suppression.Service.Delete")
+	err := s.next.Delete(ctx, email)
+	tracing.End(span, err)
+	return err
+}
+
+func (s tracedService) Suppressed(ctx context.Context, emails ...string) (map[string]bool, error) {
+	ctx, span := tracing.Start(ctx, "suppression.Service.Suppressed",
+		trace.WithAttributes(attribute.Int("emails", len(emails))))
+	suppressed, err := s.next.Suppressed(ctx, emails...)
+	tracing.End(span, err)
+	return suppressed, err
+}
+
+// UnsubscribeURL is not traced, it doesn't take context.
+func (s tracedService) UnsubscribeURL(tenantID, email string) string {
+	return s.next.UnsubscribeURL(tenantID, email)
+}
+
+func (s tracedService) Unsubscribe(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "suppression.Service.Unsubscribe")
+	err := s.next.Unsubscribe(ctx, token)
+	tracing.End(span, err)
+	return err
+}
@@ -0,0 +1,48 @@
+package tracing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vodeno/pkg/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	router := chi.NewRouter()
+	router.Use(tracing.Middleware)
+	router.Get("/clients/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.Start(r.Context(), "client.Service.Get")
+		span.End()
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/clients/5", nil)
+	req.Header.Set("traceparent", "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	child, server := spans[0], spans[1]
+
+	require.Equal(t, "GET /clients/{id}", server.Name())
+	require.Equal(t, trace.SpanKindServer, server.SpanKind())
+	require.Equal(t, "0102030405060708090a0b0c0d0e0f10", server.SpanContext().TraceID().String())
+	require.Equal(t, "0102030405060708", server.Parent().SpanID().String())
+	require.Contains(t, server.Attributes(), attribute.Int("http.status_code", http.StatusNotFound))
+	require.Equal(t, codes.Unset, server.Status().Code) // 4xx is client's error.
+
+	require.Equal(t, "client.Service.Get", child.Name())
+	require.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID())
+}
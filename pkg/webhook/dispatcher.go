@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+	"vodeno/pkg/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Dispatcher sends pending Deliveries of all tenants and retries failed ones.
+// Instances claim different Deliveries, so it can run on every replica.
+type Dispatcher struct {
+	log        logrus.FieldLogger
+	repository Repository
+	sender     *Sender
+	cfg        config.WebhookConfig
+	wg         sync.WaitGroup
+	close      chan struct{} // channel is used for graceful shutdown
+}
+
+// NewDispatcher returns new instance of Dispatcher.
+func NewDispatcher(logger *logrus.Logger, repository Repository, sender *Sender, cfg config.WebhookConfig) *Dispatcher {
+	return &Dispatcher{
+		log:        logger.WithField("place", "webhook_dispatcher"),
+		repository: repository,
+		sender:     sender,
+		cfg:        cfg,
+		close:      make(chan struct{}),
+	}
+}
+
+// Start starts dispatching goroutine.
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.wg.Add(1)
+	d.log.WithField("tick", d.cfg.TickPeriod.String()).Info("starting")
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(d.cfg.TickPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := d.Dispatch(ctx); err != nil {
+					d.log.WithError(err).Error("failed to dispatch webhooks")
+				}
+			case <-d.close:
+				d.log.Info("closing")
+				return
+			}
+		}
+	}()
+}
+
+// Dispatch sends due Deliveries until there are no more.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	for {
+		// claimed Deliveries aren't claimed again until every one of the batch could time out.
+		lease := time.Duration(d.cfg.BatchSize+1) * d.cfg.Timeout
+		deliveries, err := d.repository.ClaimDue(ctx, d.cfg.BatchSize, time.Now().Add(lease))
+		if err != nil {
+			return err
+		}
+		for _, delivery := range deliveries {
+			if err := d.send(ctx, delivery); err != nil {
+				return err
+			}
+		}
+		if len(deliveries) < d.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+// send attempts Delivery and records the result.
+func (d *Dispatcher) send(ctx context.Context, delivery Delivery) error {
+	logger := d.log.WithFields(logrus.Fields{
+		"delivery_id":     delivery.ID,
+		"subscription_id": delivery.SubscriptionID,
+	})
+	sub, err := d.repository.GetSubscription(ctx, delivery.TenantID, delivery.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	if sub == nil { // deleted meanwhile, its Deliveries are deleted too.
+		return nil
+	}
+
+	delivery = attempt(ctx, d.sender, *sub, delivery, d.cfg.MaxAttempts, d.cfg.Backoff)
+	switch delivery.State {
+	case DeliveryDelivered:
+		logger.Debug("webhook delivered")
+	case DeliveryFailed:
+		logger.WithField("error", delivery.LastError).Warn("webhook failed, giving up")
+	default:
+		logger.WithField("error", delivery.LastError).Info("webhook failed, will retry")
+	}
+	return d.repository.UpdateDelivery(ctx, delivery)
+}
+
+// Stop stops dispatcher and waits for goroutine to shutdown.
+func (d *Dispatcher) Stop() {
+	d.close <- struct{}{}
+	d.wg.Wait()
+}
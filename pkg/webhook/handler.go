@@ -0,0 +1,218 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"vodeno/pkg/audit"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/events"
+	"vodeno/pkg/httpjson"
+	"vodeno/pkg/middleware"
+	"vodeno/pkg/problem"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultLimit = 20
+
+// Handler is a http handler for webhook subscriptions.
+type Handler struct {
+	service   Service
+	validator *validator.Validate
+	log       *logrus.Logger
+}
+
+// NewHandler returns new instance of Handler.
+func NewHandler(log *logrus.Logger, svc Service) *Handler {
+	return &Handler{
+		service:   svc,
+		validator: problem.NewValidator(),
+		log:       log,
+	}
+}
+
+// AddRoutes adds webhook routes to router. They require admin scope.
+func (h *Handler) AddRoutes(router chi.Router) {
+	router.Route("/webhooks", func(r chi.Router) {
+		r.Use(middleware.RequireScope(auth.ScopeAdmin))
+		r.Post("/", h.subscribe)
+		r.Get("/", h.list)
+		r.Delete("/{id}", h.unsubscribe)
+		r.Get("/{id}/deliveries", h.deliveries)
+		r.Post("/{id}/test", h.testFire)
+	})
+}
+
+// SubscribeRequest is a subscribe handler request.
+type SubscribeRequest struct {
+	URL        string        `json:"url" validate:"required,url"`
+	Secret     string        `json:"secret" validate:"required,min=16"`
+	EventTypes []events.Type `json:"event_types" validate:"required,min=1"`
+}
+
+type listResponse struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+}
+
+type deliveriesResponse struct {
+	Deliveries []Delivery `json:"deliveries"`
+}
+
+// subscribe creates Subscription.
+func (h *Handler) subscribe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "subscribeWebhook")
+	var req SubscribeRequest
+
+	if err := httpjson.Decode(r, &req); err != nil {
+		logger.WithError(err).Error("failed to decode request")
+		problem.Write(w, r, httpjson.Problem(err))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logger.WithError(err).Error("request is not valid")
+		problem.Write(w, r, problem.Validation(err))
+		return
+	}
+
+	sub, err := h.service.Subscribe(ctx, req.URL, req.Secret, req.EventTypes)
+	if err != nil {
+		logger.WithError(err).Error("failed to subscribe webhook")
+		problem.Write(w, r, problemFor(err))
+		return
+	}
+	audit.AddTarget(ctx, "webhook_id", sub.ID)
+	h.writeJSON(w, http.StatusCreated, sub)
+}
+
+// list lists Subscriptions without their secrets.
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "listWebhooks")
+
+	subs, err := h.service.List(ctx)
+	if err != nil {
+		logger.WithError(err).Error("failed to list webhooks")
+		problem.Write(w, r, problemFor(err))
+		return
+	}
+	h.writeJSON(w, http.StatusOK, listResponse{Subscriptions: subs})
+}
+
+// unsubscribe deletes Subscription.
+func (h *Handler) unsubscribe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "unsubscribeWebhook")
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		logger.Error("id must be an integer")
+		problem.Write(w, r, problem.InvalidParameter("id", err))
+		return
+	}
+	audit.AddTarget(ctx, "webhook_id", id)
+
+	if err := h.service.Unsubscribe(ctx, id); err != nil {
+		logger.WithError(err).Error("failed to unsubscribe webhook")
+		problem.Write(w, r, problemFor(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deliveries lists Deliveries of Subscription. It accepts limit and after_id pagination.
+func (h *Handler) deliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "listWebhookDeliveries")
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		logger.Error("id must be an integer")
+		problem.Write(w, r, problem.InvalidParameter("id", err))
+		return
+	}
+	cursor := Cursor{Limit: defaultLimit}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		if cursor.Limit, err = strconv.Atoi(limit); err != nil {
+			problem.Write(w, r, problem.InvalidParameter("limit", err))
+			return
+		}
+	}
+	if afterIDStr := r.URL.Query().Get("after_id"); afterIDStr != "" {
+		afterID, err := strconv.Atoi(afterIDStr)
+		if err != nil {
+			problem.Write(w, r, problem.InvalidParameter("after_id", err))
+			return
+		}
+		cursor.AfterID = &afterID
+	}
+
+	deliveries, err := h.service.Deliveries(ctx, id, cursor)
+	if err != nil {
+		logger.WithError(err).Error("failed to list webhook deliveries")
+		problem.Write(w, r, problemFor(err))
+		return
+	}
+	if len(deliveries) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	// write after_id header.
+	w.Header().Add("after_id", strconv.Itoa(deliveries[len(deliveries)-1].ID))
+	h.writeJSON(w, http.StatusOK, deliveriesResponse{Deliveries: deliveries})
+}
+
+// testFire sends test event to Subscription and returns its Delivery, failed or not.
+func (h *Handler) testFire(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := h.log.WithContext(ctx).WithField("handler", "testFireWebhook")
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		logger.Error("id must be an integer")
+		problem.Write(w, r, problem.InvalidParameter("id", err))
+		return
+	}
+	audit.AddTarget(ctx, "webhook_id", id)
+
+	delivery, err := h.service.TestFire(ctx, id)
+	if err != nil {
+		logger.WithError(err).Error("failed to test webhook")
+		problem.Write(w, r, problemFor(err))
+		return
+	}
+	h.writeJSON(w, http.StatusOK, delivery)
+}
+
+// problemFor maps error returned by Service to Problem. Unknown errors are internal.
+func problemFor(err error) *problem.Problem {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return problem.New(http.StatusNotFound, problem.CodeNotFound, err.Error())
+	case errors.Is(err, ErrInvalidURL), errors.Is(err, ErrInvalidEventType):
+		return problem.New(http.StatusBadRequest, problem.CodeValidationFailed, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return problem.Timeout()
+	default:
+		return problem.Internal()
+	}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.log.WithError(err).Error("writing JSON to ResponseWriter failed")
+	}
+}
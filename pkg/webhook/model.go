@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+	"vodeno/pkg/events"
+
+	"github.com/lib/pq"
+)
+
+// TestEvent is a type of Events sent by Service.TestFire.
+const TestEvent events.Type = "webhook.test"
+
+// Types lists event types which can be subscribed to.
+var Types = []events.Type{events.SendCompleted, events.MessageBounced, events.MessageComplained}
+
+// Subscription is a subscription of URL to events of its tenant.
+type Subscription struct {
+	ID       int    `json:"id" db:"id"`
+	TenantID string `json:"-" db:"tenant_id"`
+	URL      string `json:"url" db:"url"`
+	// Secret signs payloads, it's never returned.
+	Secret     string         `json:"-" db:"secret"`
+	EventTypes pq.StringArray `json:"event_types" db:"event_types"`
+	CreateTime time.Time      `json:"create_time" db:"create_time"`
+}
+
+// DeliveryState is a state of Delivery.
+type DeliveryState string
+
+const (
+	DeliveryPending   DeliveryState = "pending"   // waiting for the next attempt.
+	DeliveryDelivered DeliveryState = "delivered" // receiver responded with 2xx.
+	DeliveryFailed    DeliveryState = "failed"    // all attempts failed.
+)
+
+// Delivery is a delivery of event to Subscription, it records the last attempt.
+type Delivery struct {
+	ID             int             `json:"id" db:"id"`
+	SubscriptionID int             `json:"subscription_id" db:"subscription_id"`
+	TenantID       string          `json:"-" db:"tenant_id"`
+	EventType      events.Type     `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	State          DeliveryState   `json:"state" db:"state"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	// LastStatus is HTTP status of the last attempt, it's zero if the receiver didn't respond.
+	LastStatus      int       `json:"last_status,omitempty" db:"last_status"`
+	LastError       string    `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptTime time.Time `json:"next_attempt_time" db:"next_attempt_time"`
+	InsertTime      time.Time `json:"insert_time" db:"insert_time"`
+	UpdateTime      time.Time `json:"update_time" db:"update_time"`
+}
+
+// Cursor paginates listed Deliveries.
+type Cursor struct {
+	Limit   int
+	AfterID *int
+}
@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"vodeno/pkg/events"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	subscriptionTableName = "webhook_subscription" // subscription table name.
+	deliveryTableName     = "webhook_delivery"     // delivery table name.
+)
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+// repo is postgresql implementation of Repository.
+type repo struct {
+	db *sqlx.DB
+}
+
+// NewRepo creates new instance of repo.
+func NewRepo(db *sqlx.DB) *repo {
+	return &repo{db: db}
+}
+
+func (r repo) InsertSubscription(ctx context.Context, s Subscription) (int, error) {
+	q := psql.Insert(subscriptionTableName).
+		Columns("tenant_id", "url", "secret", "event_types", "create_time").
+		Values(s.TenantID, s.URL, s.Secret, s.EventTypes, s.CreateTime).
+		Suffix("RETURNING id")
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	if err := r.db.QueryRowxContext(ctx, query, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r repo) GetSubscription(ctx context.Context, tenantID string, id int) (*Subscription, error) {
+	q := psql.Select("*").From(subscriptionTableName).Where(sq.Eq{"tenant_id": tenantID, "id": id})
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var s Subscription
+	if err := r.db.GetContext(ctx, &s, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r repo) ListSubscriptions(ctx context.Context, tenantID string) ([]Subscription, error) {
+	q := psql.Select("*").From(subscriptionTableName).Where(sq.Eq{"tenant_id": tenantID}).OrderBy("id")
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var subscriptions []Subscription
+	if err := r.db.SelectContext(ctx, &subscriptions, query, args...); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r repo) DeleteSubscription(ctx context.Context, tenantID string, id int) (bool, error) {
+	q := psql.Delete(subscriptionTableName).Where(sq.Eq{"tenant_id": tenantID, "id": id})
+	query, args, err := q.ToSql()
+	if err != nil {
+		return false, err
+	}
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r repo) Enqueue(
+	ctx context.Context, tenantID string, eventType events.Type, payload []byte, t time.Time,
+) (int64, error) {
+	subscriptions := psql.Select().
+		Column("id").Column("tenant_id").
+		Column("?::text", string(eventType)).
+		Column("?::jsonb", string(payload)).
+		Column("?", DeliveryPending).
+		Column("?::timestamptz", t).Column("?::timestamptz", t).Column("?::timestamptz", t).
+		From(subscriptionTableName).
+		Where(sq.Eq{"tenant_id": tenantID}).
+		Where("? = ANY(event_types)", string(eventType))
+	q := psql.Insert(deliveryTableName).
+		Columns("subscription_id", "tenant_id", "event_type", "payload", "state",
+			"next_attempt_time", "insert_time", "update_time").
+		Select(subscriptions)
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return 0, err
+	}
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r repo) InsertDelivery(ctx context.Context, d Delivery) (int, error) {
+	q := psql.Insert(deliveryTableName).
+		Columns("subscription_id", "tenant_id", "event_type", "payload", "state", "attempts",
+			"last_status", "last_error", "next_attempt_time", "insert_time", "update_time").
+		Values(d.SubscriptionID, d.TenantID, d.EventType, string(d.Payload), d.State, d.Attempts,
+			d.LastStatus, d.LastError, d.NextAttemptTime, d.InsertTime, d.UpdateTime).
+		Suffix("RETURNING id")
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	if err := r.db.QueryRowxContext(ctx, query, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// ClaimDue locks due rows with SKIP LOCKED, so concurrent instances claim different Deliveries.
+func (r repo) ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]Delivery, error) {
+	query := `WITH due AS (
+		SELECT id FROM ` + deliveryTableName + `
+		WHERE state = $1 AND next_attempt_time <= now()
+		ORDER BY next_attempt_time
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	)
+	UPDATE ` + deliveryTableName + ` d SET next_attempt_time = $3
+	FROM due WHERE d.id = due.id
+	RETURNING d.*`
+
+	var deliveries []Delivery
+	if err := r.db.SelectContext(ctx, &deliveries, query, DeliveryPending, limit, leaseUntil); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r repo) UpdateDelivery(ctx context.Context, d Delivery) error {
+	q := psql.Update(deliveryTableName).
+		Set("state", d.State).
+		Set("attempts", d.Attempts).
+		Set("last_status", d.LastStatus).
+		Set("last_error", d.LastError).
+		Set("next_attempt_time", d.NextAttemptTime).
+		Set("update_time", d.UpdateTime).
+		Where(sq.Eq{"id": d.ID})
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r repo) ListDeliveries(
+	ctx context.Context, tenantID string, subscriptionID int, cursor Cursor,
+) ([]Delivery, error) {
+	q := psql.Select("*").From(deliveryTableName).
+		Where(sq.Eq{"tenant_id": tenantID, "subscription_id": subscriptionID}).
+		OrderBy("id")
+	if cursor.AfterID != nil {
+		q = q.Where(sq.Gt{"id": *cursor.AfterID})
+	}
+	if cursor.Limit > 0 {
+		q = q.Limit(uint64(cursor.Limit))
+	}
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []Delivery
+	if err := r.db.SelectContext(ctx, &deliveries, query, args...); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+	"vodeno/pkg/events"
+)
+
+// publisher is events.Publisher which also enqueues Deliveries of published events to their Subscriptions.
+type publisher struct {
+	next       events.Publisher
+	repository Repository
+}
+
+// NewPublisher wraps next, so events it publishes are delivered to subscribed webhooks too.
+// Deliveries are enqueued by the instance publishing the event, so each is sent once.
+func NewPublisher(next events.Publisher, repository Repository) events.Publisher {
+	return publisher{next: next, repository: repository}
+}
+
+// Publish publishes events with next Publisher and enqueues their Deliveries,
+// one failing doesn't prevent the other.
+func (p publisher) Publish(ctx context.Context, evs ...events.Event) error {
+	publishErr := p.next.Publish(ctx, evs...)
+	for _, e := range evs {
+		if !subscribable(e.Type) {
+			continue
+		}
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		t := e.Time
+		if t.IsZero() {
+			t = time.Now()
+		}
+		if _, err := p.repository.Enqueue(ctx, e.TenantID, e.Type, payload, t); err != nil {
+			return err
+		}
+	}
+	return publishErr
+}
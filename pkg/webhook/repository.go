@@ -0,0 +1,33 @@
+package webhook
+
+import (
+	"context"
+	"time"
+	"vodeno/pkg/events"
+)
+
+// Repository is a repository interface.
+type Repository interface {
+	// InsertSubscription inserts Subscription to storage and returns its ID.
+	InsertSubscription(ctx context.Context, s Subscription) (int, error)
+	// GetSubscription queries Subscription of a tenant. It returns nil if there is no such Subscription.
+	GetSubscription(ctx context.Context, tenantID string, id int) (*Subscription, error)
+	// ListSubscriptions lists Subscriptions of a tenant.
+	ListSubscriptions(ctx context.Context, tenantID string) ([]Subscription, error)
+	// DeleteSubscription deletes Subscription of a tenant with its Deliveries.
+	// It returns false if there is no such Subscription.
+	DeleteSubscription(ctx context.Context, tenantID string, id int) (bool, error)
+
+	// Enqueue inserts pending Delivery of payload for every Subscription of the tenant to event type.
+	// It returns number of inserted Deliveries.
+	Enqueue(ctx context.Context, tenantID string, eventType events.Type, payload []byte, t time.Time) (int64, error)
+	// InsertDelivery inserts Delivery to storage and returns its ID.
+	InsertDelivery(ctx context.Context, d Delivery) (int, error)
+	// ClaimDue returns up to limit pending Deliveries of all tenants whose next attempt is due
+	// and postpones their next attempt to leaseUntil, so other instances don't claim them too.
+	ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]Delivery, error)
+	// UpdateDelivery updates state, attempts, last status and error and next attempt time of Delivery.
+	UpdateDelivery(ctx context.Context, d Delivery) error
+	// ListDeliveries lists Deliveries of a Subscription of a tenant with pagination.
+	ListDeliveries(ctx context.Context, tenantID string, subscriptionID int, cursor Cursor) ([]Delivery, error)
+}
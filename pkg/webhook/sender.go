@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"vodeno/pkg/events"
+	"vodeno/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxErrorBody is the number of bytes of error response recorded in Delivery's last error.
+const maxErrorBody = 512
+
+// envelope is a JSON body of webhook requests.
+type envelope struct {
+	ID        int             `json:"id"`
+	Type      events.Type     `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Sender posts signed Deliveries to subscribed URLs.
+type Sender struct {
+	httpClient *http.Client
+}
+
+// NewSender returns Sender whose requests time out after timeout.
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{httpClient: &http.Client{Timeout: timeout}}
+}
+
+// Send posts Delivery to Subscription's URL. It returns HTTP status of the response, if there was one,
+// and error if there was no response or its status isn't 2xx.
+func (s *Sender) Send(ctx context.Context, sub Subscription, d Delivery) (status int, err error) {
+	ctx, span := tracing.Start(ctx, "webhook.Send", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("delivery_id", d.ID), attribute.String("event_type", string(d.EventType))))
+	defer func() { tracing.End(span, err) }()
+
+	body, err := json.Marshal(envelope{ID: d.ID, Type: d.EventType, CreatedAt: d.InsertTime, Data: d.Payload})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "vodeno-webhook")
+	req.Header.Set(EventHeader, string(d.EventType))
+	req.Header.Set(DeliveryHeader, strconv.Itoa(d.ID))
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, time.Now(), body))
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("receiver responded with %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
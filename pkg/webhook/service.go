@@ -0,0 +1,210 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/events"
+)
+
+// maxBackoff limits delay between delivery attempts.
+const maxBackoff = time.Hour
+
+var (
+	// ErrNotFound is returned when Subscription with given ID doesn't exist.
+	ErrNotFound = errors.New("webhook subscription not found")
+	// ErrInvalidURL is returned when subscribed URL isn't absolute http or https URL.
+	ErrInvalidURL = errors.New("webhook URL must be absolute http or https URL")
+	// ErrInvalidEventType is returned when subscribed event type can't be subscribed to, see Types.
+	ErrInvalidEventType = errors.New("invalid event type")
+)
+
+// Service is a service interface. All methods are scoped by tenant from context,
+// they return auth.ErrNoTenant if there is none.
+type Service interface {
+	// Subscribe subscribes URL to events of given types, payloads are signed with secret.
+	Subscribe(ctx context.Context, rawURL, secret string, eventTypes []events.Type) (*Subscription, error)
+	// List lists Subscriptions.
+	List(ctx context.Context) ([]Subscription, error)
+	// Unsubscribe deletes Subscription and its Deliveries.
+	Unsubscribe(ctx context.Context, id int) error
+	// Deliveries lists Deliveries of Subscription, the latest attempt of each.
+	Deliveries(ctx context.Context, id int, cursor Cursor) ([]Delivery, error)
+	// TestFire sends TestEvent to Subscription right away, without retries, and returns its Delivery.
+	// Failure of the receiver is recorded in Delivery, it's not returned as error.
+	TestFire(ctx context.Context, id int) (*Delivery, error)
+}
+
+// service implements Service interface.
+type service struct {
+	repository Repository
+	sender     *Sender
+}
+
+// NewService returns new Service.
+func NewService(repository Repository, sender *Sender) Service {
+	return service{
+		repository: repository,
+		sender:     sender,
+	}
+}
+
+func (s service) Subscribe(ctx context.Context, rawURL, secret string, eventTypes []events.Type) (*Subscription, error) {
+	tenantID, ok := auth.TenantFromContext(ctx)
+	if !ok {
+		return nil, auth.ErrNoTenant
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidURL
+	}
+	types := make([]string, 0, len(eventTypes))
+	for _, t := range eventTypes {
+		if !subscribable(t) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidEventType, t)
+		}
+		types = append(types, string(t))
+	}
+
+	sub := Subscription{
+		TenantID:   tenantID,
+		URL:        rawURL,
+		Secret:     secret,
+		EventTypes: types,
+		CreateTime: time.Now(),
+	}
+	if sub.ID, err = s.repository.InsertSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (s service) List(ctx context.Context) ([]Subscription, error) {
+	tenantID, ok := auth.TenantFromContext(ctx)
+	if !ok {
+		return nil, auth.ErrNoTenant
+	}
+	return s.repository.ListSubscriptions(ctx, tenantID)
+}
+
+func (s service) Unsubscribe(ctx context.Context, id int) error {
+	tenantID, ok := auth.TenantFromContext(ctx)
+	if !ok {
+		return auth.ErrNoTenant
+	}
+	deleted, err := s.repository.DeleteSubscription(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("subscription %d: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (s service) Deliveries(ctx context.Context, id int, cursor Cursor) ([]Delivery, error) {
+	sub, err := s.subscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repository.ListDeliveries(ctx, sub.TenantID, sub.ID, cursor)
+}
+
+func (s service) TestFire(ctx context.Context, id int) (*Delivery, error) {
+	sub, err := s.subscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	payload, err := json.Marshal(events.Event{Type: TestEvent, TenantID: sub.TenantID, Time: now})
+	if err != nil {
+		return nil, err
+	}
+	d := Delivery{
+		SubscriptionID:  sub.ID,
+		TenantID:        sub.TenantID,
+		EventType:       TestEvent,
+		Payload:         payload,
+		State:           DeliveryPending,
+		NextAttemptTime: now,
+		InsertTime:      now,
+		UpdateTime:      now,
+	}
+	// Delivery is stored first, so the receiver gets its ID.
+	if d.ID, err = s.repository.InsertDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+	d = attempt(ctx, s.sender, *sub, d, 1, 0)
+	if err := s.repository.UpdateDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// subscription returns Subscription of tenant from context.
+func (s service) subscription(ctx context.Context, id int) (*Subscription, error) {
+	tenantID, ok := auth.TenantFromContext(ctx)
+	if !ok {
+		return nil, auth.ErrNoTenant
+	}
+	sub, err := s.repository.GetSubscription(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, fmt.Errorf("subscription %d: %w", id, ErrNotFound)
+	}
+	return sub, nil
+}
+
+// attempt sends Delivery and returns it updated with the result. Failed Delivery stays pending
+// with the next attempt after backoff doubled with every attempt, until maxAttempts are made.
+func attempt(
+	ctx context.Context, sender *Sender, sub Subscription, d Delivery, maxAttempts int, backoff time.Duration,
+) Delivery {
+	status, err := sender.Send(ctx, sub, d)
+
+	now := time.Now()
+	d.Attempts++
+	d.LastStatus = status
+	d.LastError = ""
+	d.UpdateTime = now
+	switch {
+	case err == nil:
+		d.State = DeliveryDelivered
+	case d.Attempts >= maxAttempts:
+		d.State = DeliveryFailed
+		d.LastError = err.Error()
+	default:
+		d.State = DeliveryPending
+		d.LastError = err.Error()
+		d.NextAttemptTime = now.Add(retryDelay(d.Attempts, backoff))
+	}
+	return d
+}
+
+// retryDelay returns delay after given number of failed attempts.
+func retryDelay(attempts int, backoff time.Duration) time.Duration {
+	delay := backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
+
+// subscribable returns true if event type can be subscribed to.
+func subscribable(t events.Type) bool {
+	for _, s := range Types {
+		if s == t {
+			return true
+		}
+	}
+	return false
+}
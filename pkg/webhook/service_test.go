@@ -0,0 +1,351 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"vodeno/pkg/auth"
+	"vodeno/pkg/config"
+	"vodeno/pkg/events"
+	"vodeno/pkg/webhook"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "0123456789abcdef"
+
+// memoryRepo is in-memory implementation of webhook.Repository.
+type memoryRepo struct {
+	mu            sync.Mutex
+	subscriptions []webhook.Subscription
+	deliveries    []webhook.Delivery
+}
+
+func (m *memoryRepo) InsertSubscription(_ context.Context, s webhook.Subscription) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.ID = len(m.subscriptions) + 1
+	m.subscriptions = append(m.subscriptions, s)
+	return s.ID, nil
+}
+
+func (m *memoryRepo) GetSubscription(_ context.Context, tenantID string, id int) (*webhook.Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.subscriptions {
+		if s.ID == id && s.TenantID == tenantID {
+			return &s, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryRepo) ListSubscriptions(_ context.Context, tenantID string) ([]webhook.Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var subs []webhook.Subscription
+	for _, s := range m.subscriptions {
+		if s.TenantID == tenantID {
+			subs = append(subs, s)
+		}
+	}
+	return subs, nil
+}
+
+func (m *memoryRepo) DeleteSubscription(_ context.Context, tenantID string, id int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, s := range m.subscriptions {
+		if s.ID == id && s.TenantID == tenantID {
+			m.subscriptions = append(m.subscriptions[:i], m.subscriptions[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryRepo) Enqueue(
+	_ context.Context, tenantID string, eventType events.Type, payload []byte, t time.Time,
+) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for _, s := range m.subscriptions {
+		if s.TenantID != tenantID {
+			continue
+		}
+		for _, st := range s.EventTypes {
+			if st == string(eventType) {
+				m.deliveries = append(m.deliveries, webhook.Delivery{
+					ID:              len(m.deliveries) + 1,
+					SubscriptionID:  s.ID,
+					TenantID:        tenantID,
+					EventType:       eventType,
+					Payload:         payload,
+					State:           webhook.DeliveryPending,
+					NextAttemptTime: t,
+					InsertTime:      t,
+					UpdateTime:      t,
+				})
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+func (m *memoryRepo) InsertDelivery(_ context.Context, d webhook.Delivery) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d.ID = len(m.deliveries) + 1
+	m.deliveries = append(m.deliveries, d)
+	return d.ID, nil
+}
+
+func (m *memoryRepo) ClaimDue(_ context.Context, limit int, leaseUntil time.Time) ([]webhook.Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []webhook.Delivery
+	for i, d := range m.deliveries {
+		if len(due) == limit {
+			break
+		}
+		if d.State == webhook.DeliveryPending && !d.NextAttemptTime.After(time.Now()) {
+			m.deliveries[i].NextAttemptTime = leaseUntil
+			due = append(due, m.deliveries[i])
+		}
+	}
+	return due, nil
+}
+
+func (m *memoryRepo) UpdateDelivery(_ context.Context, d webhook.Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries[d.ID-1] = d
+	return nil
+}
+
+func (m *memoryRepo) ListDeliveries(
+	_ context.Context, tenantID string, subscriptionID int, cursor webhook.Cursor,
+) ([]webhook.Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deliveries []webhook.Delivery
+	for _, d := range m.deliveries {
+		if d.TenantID == tenantID && d.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+// nopPublisher is events.Publisher which drops events.
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(context.Context, ...events.Event) error {
+	return nil
+}
+
+// receiver is a webhook receiver responding with statuses in order, the last one repeats.
+type receiver struct {
+	t        *testing.T
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	require.NoError(rc.t, err)
+	require.NoError(rc.t, webhook.Verify(secret, r.Header.Get(webhook.SignatureHeader), body, time.Minute, time.Now()))
+	require.NotEmpty(rc.t, r.Header.Get(webhook.DeliveryHeader))
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.bodies = append(rc.bodies, body)
+	status := rc.statuses[0]
+	if len(rc.statuses) > 1 {
+		rc.statuses = rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func tenantContext(tenantID string) context.Context {
+	return auth.NewContext(context.Background(), &auth.Principal{ID: "apikey:1", TenantID: tenantID})
+}
+
+func TestService_Subscribe(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		url         string
+		eventTypes  []events.Type
+		wantedError error
+	}{
+		{
+			name:       "Subscribes",
+			url:        "https://crm.example.com/hooks",
+			eventTypes: []events.Type{events.SendCompleted, events.MessageBounced},
+		},
+		{
+			name:        "ReturnsErrorOnRelativeURL",
+			url:         "/hooks",
+			eventTypes:  []events.Type{events.SendCompleted},
+			wantedError: webhook.ErrInvalidURL,
+		},
+		{
+			name:        "ReturnsErrorOnUnknownEventType",
+			url:         "https://crm.example.com/hooks",
+			eventTypes:  []events.Type{events.EntryCreated},
+			wantedError: webhook.ErrInvalidEventType,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := webhook.NewService(&memoryRepo{}, webhook.NewSender(time.Second))
+
+			sub, err := svc.Subscribe(tenantContext("t1"), tt.url, secret, tt.eventTypes)
+			if tt.wantedError != nil {
+				require.ErrorIs(t, err, tt.wantedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "t1", sub.TenantID)
+			require.NotZero(t, sub.ID)
+		})
+	}
+}
+
+func TestService_TestFire(t *testing.T) {
+	rc := &receiver{t: t, statuses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	repo := &memoryRepo{}
+	svc := webhook.NewService(repo, webhook.NewSender(time.Second))
+	ctx := tenantContext("t1")
+	sub, err := svc.Subscribe(ctx, server.URL, secret, []events.Type{events.SendCompleted})
+	require.NoError(t, err)
+
+	// test-fire isn't retried.
+	delivery, err := svc.TestFire(ctx, sub.ID)
+	require.NoError(t, err)
+	require.Equal(t, webhook.DeliveryFailed, delivery.State)
+	require.Equal(t, http.StatusInternalServerError, delivery.LastStatus)
+	require.Equal(t, 1, delivery.Attempts)
+
+	deliveries, err := svc.Deliveries(ctx, sub.ID, webhook.Cursor{})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+
+	_, err = svc.TestFire(tenantContext("t2"), sub.ID)
+	require.ErrorIs(t, err, webhook.ErrNotFound)
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	log := logrus.New()
+	log.Out = io.Discard
+
+	for _, tt := range []struct {
+		name           string
+		statuses       []int
+		wantedState    webhook.DeliveryState
+		wantedAttempts int
+	}{
+		{
+			name:           "Delivers",
+			statuses:       []int{http.StatusNoContent},
+			wantedState:    webhook.DeliveryDelivered,
+			wantedAttempts: 1,
+		},
+		{
+			name:           "RetriesFailedDelivery",
+			statuses:       []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			wantedState:    webhook.DeliveryDelivered,
+			wantedAttempts: 3,
+		},
+		{
+			name:           "GivesUpAfterMaxAttempts",
+			statuses:       []int{http.StatusInternalServerError},
+			wantedState:    webhook.DeliveryFailed,
+			wantedAttempts: 4,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &receiver{t: t, statuses: tt.statuses}
+			server := httptest.NewServer(rc)
+			defer server.Close()
+
+			repo := &memoryRepo{}
+			sender := webhook.NewSender(time.Second)
+			svc := webhook.NewService(repo, sender)
+			sub, err := svc.Subscribe(tenantContext("t1"), server.URL, secret, []events.Type{events.SendCompleted})
+			require.NoError(t, err)
+			_, err = svc.Subscribe(tenantContext("t2"), server.URL, secret, []events.Type{events.SendCompleted})
+			require.NoError(t, err)
+
+			publisher := webhook.NewPublisher(nopPublisher{}, repo)
+			require.NoError(t, publisher.Publish(context.Background(),
+				events.Event{Type: events.SendCompleted, TenantID: "t1", MailingID: 5, Sent: 10},
+				events.Event{Type: events.SendStarted, TenantID: "t1", MailingID: 5},
+			))
+
+			// zero backoff makes failed deliveries due again right away.
+			dispatcher := webhook.NewDispatcher(log, repo, sender, config.WebhookConfig{
+				Timeout: time.Second, MaxAttempts: 4, BatchSize: 10,
+			})
+			for i := 0; i < 5; i++ {
+				require.NoError(t, dispatcher.Dispatch(context.Background()))
+			}
+
+			deliveries, err := svc.Deliveries(tenantContext("t1"), sub.ID, webhook.Cursor{})
+			require.NoError(t, err)
+			require.Len(t, deliveries, 1)
+			require.Equal(t, tt.wantedState, deliveries[0].State)
+			require.Equal(t, tt.wantedAttempts, deliveries[0].Attempts)
+			require.Len(t, rc.bodies, tt.wantedAttempts)
+
+			var body struct {
+				ID   int          `json:"id"`
+				Type events.Type  `json:"type"`
+				Data events.Event `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(rc.bodies[0], &body))
+			require.Equal(t, deliveries[0].ID, body.ID)
+			require.Equal(t, events.SendCompleted, body.Type)
+			require.Equal(t, 10, body.Data.Sent)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	now := time.Now()
+	header := webhook.Sign(secret, now, body)
+
+	for _, tt := range []struct {
+		name    string
+		secret  string
+		body    []byte
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "AcceptsValidSignature", secret: secret, body: body, now: now},
+		{name: "RejectsOtherSecret", secret: "other", body: body, now: now, wantErr: true},
+		{name: "RejectsChangedBody", secret: secret, body: []byte(`{"id":2}`), now: now, wantErr: true},
+		{name: "RejectsOldSignature", secret: secret, body: body, now: now.Add(time.Hour), wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := webhook.Verify(tt.secret, header, tt.body, 5*time.Minute, tt.now)
+			if tt.wantErr {
+				require.ErrorIs(t, err, webhook.ErrInvalidSignature)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"context"
+	"vodeno/pkg/events"
+	"vodeno/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedService is a Service which records span of every call.
+type tracedService struct {
+	next Service
+}
+
+// NewTracedService wraps svc to record span of every call.
+func NewTracedService(svc Service) Service {
+	return tracedService{next: svc}
+}
+
+func (s tracedService) Subscribe(
+	ctx context.Context, rawURL, secret string, eventTypes []events.Type,
+) (*Subscription, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Subscribe")
+	sub, err := s.next.Subscribe(ctx, rawURL, secret, eventTypes)
+	tracing.End(span, err)
+	return sub, err
+}
+
+func (s tracedService) List(ctx context.Context) ([]Subscription, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.List")
+	subs, err := s.next.List(ctx)
+	tracing.End(span, err)
+	return subs, err
+}
+
+func (s tracedService) Unsubscribe(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Unsubscribe", trace.WithAttributes(attribute.Int("id", id)))
+	err := s.next.Unsubscribe(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (s tracedService) Deliveries(ctx context.Context, id int, cursor Cursor) ([]Delivery, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Deliveries", trace.WithAttributes(attribute.Int("id", id)))
+	deliveries, err := s.next.Deliveries(ctx, id, cursor)
+	tracing.End(span, err)
+	return deliveries, err
+}
+
+func (s tracedService) TestFire(ctx context.Context, id int) (*Delivery, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.TestFire", trace.WithAttributes(attribute.Int("id", id)))
+	delivery, err := s.next.TestFire(ctx, id)
+	tracing.End(span, err)
+	return delivery, err
+}